@@ -22,6 +22,12 @@ var logLevel = INFO // Default log level
 func SetLogLevel(level int) {
 	logLevel = level
 }
+
+// GetLogLevel returns the global log level
+func GetLogLevel() int {
+	return logLevel
+}
+
 func ConvertStringToLogLevel(level string) int {
 	// make the level uppercase
 	level = strings.ToUpper(level)
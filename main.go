@@ -7,31 +7,37 @@ import (
 	"go-cloc/scanner"
 	"go-cloc/utilities"
 	"path/filepath"
+	"strings"
 )
 
 func main() {
 	// parse CLI arguments and store them in a struct
 	args := utilities.ParseArgsFromCLI()
 
-	// scan LOC for the directory
-	logger.Info("Scanning ", args.LocalScanFilePath, "...")
-	filePaths := scanner.WalkDirectory(args.LocalScanFilePath, args.IgnorePatterns)
-	fileScanResultsArr := []scanner.FileScanResults{}
-	for _, filePath := range filePaths {
-		fileScanResultsArr = append(fileScanResultsArr, scanner.ScanFile(filePath))
+	// scan LOC for the directories
+	// the directories are walked, the files are scanned and the results are summed up at the same time
+	scanPaths := strings.Join(args.LocalScanFilePaths, ", ")
+	logger.Info("Scanning ", scanPaths, "...")
+	fileScanResults := scanner.ScanDirectoriesToChannel(args.LocalScanFilePaths, args.IgnoreFiles, args.Workers)
+
+	// per file results are only kept for the CSV, the file tree is only built for the HTML report
+	aggregator := report.NewAggregator(args.ExcludeGenerated, args.CsvFilePath != "", args.HtmlReportsDirectoryPath != "", args.LocalScanFilePaths)
+	for result := range fileScanResults {
+		aggregator.Add(result)
 	}
 
 	logger.Debug("Calculating total LOC ...")
-
-	// sort and calculate total LOC
-	fileScanResultsArr = report.SortFileScanResults(fileScanResultsArr)
-	repoTotalResult := report.CalculateTotalLineOfCode(fileScanResultsArr)
-
-	// convert results into records for CSV or command line output
-	records := report.ConvertFileResultsIntoRecords(fileScanResultsArr, repoTotalResult)
+	aggregator.Sort()
+	repoTotalResult := aggregator.Total
 
 	// Dump results by file in a csv
 	if args.CsvFilePath != "" {
+		// convert results into records for CSV output
+		records := report.ConvertFileResultsIntoRecords(aggregator.FileResults, repoTotalResult, aggregator.GeneratedTotal)
+		records = append(records, report.ConvertLanguageTotalsIntoRecords(aggregator.LanguageToCodeLineCount)...)
+		records = append(records, report.ConvertRepoTotalsIntoRecords(aggregator.RepoTotals)...)
+		records = append(records, report.ConvertSkippedFilesIntoRecords(aggregator.SkippedFiles)...)
+
 		logger.Debug("Dumping results by file to ", args.CsvFilePath)
 		report.WriteCsv(args.CsvFilePath, records)
 		logger.Info("Done! Results can be found ", args.CsvFilePath)
@@ -39,7 +45,7 @@ func main() {
 
 	if args.HtmlReportsDirectoryPath != "" {
 		logger.Info("Dumping HTML report to ", args.HtmlReportsDirectoryPath)
-		fileNames, fileContents := report.GenerateHTMLReports(fileScanResultsArr)
+		fileNames, fileContents := report.GenerateHTMLReportsFromTree(aggregator.FileTree, aggregator.SkippedFiles, aggregator.GeneratedTotal, args.ExcludeGenerated)
 
 		for index, _ := range fileNames {
 			fileName := fileNames[index]
@@ -47,12 +53,15 @@ func main() {
 			report.WriteStringToFile(filepath.Join(args.HtmlReportsDirectoryPath, fileName), fileContent)
 		}
 		report.DumpSVGs(args.HtmlReportsDirectoryPath)
-		logger.Info("Done! HTML report for ", args.LocalScanFilePath, " can be found in ", args.HtmlReportsDirectoryPath)
+		logger.Info("Done! HTML report for ", scanPaths, " can be found in ", args.HtmlReportsDirectoryPath)
 	}
 
-	report.PrintResultsToCommandLine(repoTotalResult.CodeLineCount, repoTotalResult.CommentsLineCount, repoTotalResult.BlankLineCount)
+	report.PrintSkippedFilesToCommandLine(aggregator.SkippedFiles)
+	report.PrintGeneratedFilesToCommandLine(aggregator.GeneratedTotal, aggregator.GeneratedFileCount, args.ExcludeGenerated)
+	report.PrintRepoTotalsToCommandLine(aggregator.RepoTotals)
+	report.PrintResultsToCommandLine(repoTotalResult.CodeLineCount, repoTotalResult.CommentsLineCount, repoTotalResult.BlankLineCount, repoTotalResult.MixedLineCount)
 	logger.Info("For detailed reporting, please use the --csv or --html options. For more information, please refer to the README.md file. ")
-	logger.Info("Total LOC for ", args.LocalScanFilePath, " is ", repoTotalResult.CodeLineCount)
+	logger.Info("Total LOC for ", scanPaths, " is ", repoTotalResult.CodeLineCount)
 
 	// Print the total LOC to standard output to make it easy for external tools to parse
 	fmt.Println(repoTotalResult.CodeLineCount)
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"go-cloc/logger"
+	"go-cloc/scanner"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Aggregator sums up the file scan results one file at a time, so the totals can be built while the files are still being scanned
+// The per file results and the file tree are only kept when a report needs them
+type Aggregator struct {
+	ExcludeGenerated        bool                      // leaves generated and minified files out of the total, the language totals and the file tree
+	Total                   scanner.FileScanResults   // total of the counted files
+	GeneratedTotal          scanner.FileScanResults   // total of the generated and minified files, counted or not
+	GeneratedFileCount      int                       // number of generated and minified files
+	LanguageToCodeLineCount map[string]int            // lines of code by language of the counted files
+	FileResults             []scanner.FileScanResults // results of all scanned files, nil unless kept
+	SkippedFiles            []scanner.FileScanResults // files that were not counted, ex: binaries
+	FileTree                *FileTreeComponent        // tree of the counted files, nil unless built
+	RepoTotals              []RepoTotal               // lines of code of the counted files by scanned directory, in the order the directories were given
+	repoRoots               []string                  // absolute paths of the scanned directories, in the same order as RepoTotals
+}
+
+// NewAggregator creates an empty Aggregator
+// keepFileResults keeps the result of each file, ex: for the CSV report, buildFileTree builds the tree for the HTML report
+// scanPaths are the scanned files or directories, each file adds to the total of the first one it is in
+func NewAggregator(excludeGenerated bool, keepFileResults bool, buildFileTree bool, scanPaths []string) *Aggregator {
+	aggregator := &Aggregator{
+		ExcludeGenerated:        excludeGenerated,
+		Total:                   scanner.FileScanResults{FilePath: "total"},
+		GeneratedTotal:          scanner.FileScanResults{FilePath: "generated"},
+		LanguageToCodeLineCount: map[string]int{},
+		SkippedFiles:            []scanner.FileScanResults{},
+		RepoTotals:              []RepoTotal{},
+		repoRoots:               []string{},
+	}
+	for _, scanPath := range scanPaths {
+		absScanPath, err := filepath.Abs(scanPath)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+		aggregator.RepoTotals = append(aggregator.RepoTotals, RepoTotal{RepositoryId: scanPath})
+		aggregator.repoRoots = append(aggregator.repoRoots, absScanPath)
+	}
+	if keepFileResults {
+		aggregator.FileResults = []scanner.FileScanResults{}
+	}
+	if buildFileTree {
+		aggregator.FileTree = newFileTree()
+	}
+	return aggregator
+}
+
+// Add adds the result of a single file to the totals
+func (aggregator *Aggregator) Add(result scanner.FileScanResults) {
+	if result.SkipReason != "" {
+		aggregator.SkippedFiles = append(aggregator.SkippedFiles, result)
+		return
+	}
+	// generated and minified files stay in the per file results but can be left out of the total
+	if aggregator.FileResults != nil {
+		aggregator.FileResults = append(aggregator.FileResults, result)
+	}
+	if result.IsGenerated {
+		addToTotal(&aggregator.GeneratedTotal, result)
+		aggregator.GeneratedFileCount++
+		if aggregator.ExcludeGenerated {
+			return
+		}
+	}
+	addToTotal(&aggregator.Total, result)
+	addToLanguageToCodeLineCount(aggregator.LanguageToCodeLineCount, result)
+	for i, repoRoot := range aggregator.repoRoots {
+		if isInsideRepoRoot(repoRoot, result.FilePath) {
+			aggregator.RepoTotals[i].CodeLineCount += result.CodeLineCount
+			break
+		}
+	}
+	if aggregator.FileTree != nil {
+		addFileToTree(aggregator.FileTree, result)
+	}
+}
+
+// Sort sorts the per file results and the skipped files once all files are added
+// The files arrive in the order they finished scanning, sorting makes the reports the same on every run
+func (aggregator *Aggregator) Sort() {
+	if aggregator.FileResults != nil {
+		aggregator.FileResults = SortFileScanResults(aggregator.FileResults)
+	}
+	sort.Slice(aggregator.SkippedFiles, func(a, b int) bool {
+		return aggregator.SkippedFiles[a].FilePath < aggregator.SkippedFiles[b].FilePath
+	})
+}
+
+// checks if the file is the scanned path itself or inside of it, both paths must be absolute
+func isInsideRepoRoot(repoRoot string, filePath string) bool {
+	if filePath == repoRoot {
+		return true
+	}
+	if !strings.HasSuffix(repoRoot, string(os.PathSeparator)) {
+		repoRoot += string(os.PathSeparator)
+	}
+	return strings.HasPrefix(filePath, repoRoot)
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"go-cloc/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_aggregator_matches_aggregating_all_results(t *testing.T) {
+	fileScanResults := []scanner.FileScanResults{}
+	for _, filePath := range scanner.WalkDirectory("../scanner/test-files", []scanner.IgnoreFile{}) {
+		fileScanResults = append(fileScanResults, scanner.ScanFile(filePath))
+	}
+	scannedFiles := []scanner.FileScanResults{}
+	countedFiles := []scanner.FileScanResults{}
+	generatedFiles := []scanner.FileScanResults{}
+	for _, result := range fileScanResults {
+		if result.SkipReason != "" {
+			continue
+		}
+		scannedFiles = append(scannedFiles, result)
+		if result.IsGenerated {
+			generatedFiles = append(generatedFiles, result)
+		} else {
+			countedFiles = append(countedFiles, result)
+		}
+	}
+	languageToCodeLineCount := map[string]int{}
+	for _, result := range countedFiles {
+		addToLanguageToCodeLineCount(languageToCodeLineCount, result)
+	}
+
+	// the same way main scans and sums up the files
+	aggregator := NewAggregator(true, true, true, []string{"../scanner/test-files"})
+	for result := range scanner.ScanDirectoriesToChannel([]string{"../scanner/test-files"}, []scanner.IgnoreFile{}, 4) {
+		aggregator.Add(result)
+	}
+	aggregator.Sort()
+
+	// Assert
+	assert.Equal(t, SortFileScanResults(scannedFiles), aggregator.FileResults)
+	assert.Equal(t, CalculateTotalLineOfCode(countedFiles), aggregator.Total)
+	assert.Equal(t, CalculateTotalLineOfCode(generatedFiles).CodeLineCount, aggregator.GeneratedTotal.CodeLineCount)
+	assert.Equal(t, len(generatedFiles), aggregator.GeneratedFileCount)
+	assert.Equal(t, languageToCodeLineCount, aggregator.LanguageToCodeLineCount)
+	assert.Equal(t, len(fileScanResults)-len(scannedFiles), len(aggregator.SkippedFiles))
+	assert.NotEmpty(t, aggregator.SkippedFiles)
+	assert.NotZero(t, aggregator.GeneratedFileCount)
+
+	// the tree built while scanning is the same as the tree built from all results
+	expectedFileNames, expectedFileContents := GenerateHTMLReportsFromTree(createTreeFromScanResults(countedFiles), aggregator.SkippedFiles, aggregator.GeneratedTotal, true)
+	fileNames, fileContents := GenerateHTMLReportsFromTree(aggregator.FileTree, aggregator.SkippedFiles, aggregator.GeneratedTotal, true)
+	assert.Equal(t, expectedFileNames, fileNames)
+	assert.Equal(t, expectedFileContents, fileContents)
+}
+
+func Test_aggregator_counts_generated_files_unless_excluded(t *testing.T) {
+	results := []scanner.FileScanResults{
+		{FilePath: "/a.js", LanguageName: "JavaScript", CodeLineCount: 10},
+		{FilePath: "/a.min.js", LanguageName: "JavaScript", CodeLineCount: 1, IsGenerated: true},
+		{FilePath: "/a.png", SkipReason: "unsupported file type"},
+	}
+	for _, excludeGenerated := range []bool{false, true} {
+		aggregator := NewAggregator(excludeGenerated, false, false, []string{})
+		for _, result := range results {
+			aggregator.Add(result)
+		}
+
+		// Assert
+		expectedCodeLineCount := 11
+		if excludeGenerated {
+			expectedCodeLineCount = 10
+		}
+		assert.Equal(t, expectedCodeLineCount, aggregator.Total.CodeLineCount)
+		assert.Equal(t, map[string]int{"JavaScript": expectedCodeLineCount}, aggregator.LanguageToCodeLineCount)
+		assert.Equal(t, 1, aggregator.GeneratedTotal.CodeLineCount)
+		assert.Equal(t, 1, aggregator.GeneratedFileCount)
+		assert.Equal(t, 1, len(aggregator.SkippedFiles))
+		assert.Nil(t, aggregator.FileResults)
+		assert.Nil(t, aggregator.FileTree)
+	}
+}
+
+func Test_aggregator_totals_by_scan_path(t *testing.T) {
+	scanPaths := []string{"../scanner/test-files/js", "../scanner/test-files", "../scanner/test-files/js/"}
+	aggregator := NewAggregator(false, true, false, scanPaths)
+	for result := range scanner.ScanDirectoriesToChannel(scanPaths, []scanner.IgnoreFile{}, 4) {
+		aggregator.Add(result)
+	}
+	jsTotal := scanner.FileScanResults{}
+	for _, filePath := range scanner.WalkDirectory("../scanner/test-files/js", []scanner.IgnoreFile{}) {
+		addToTotal(&jsTotal, scanner.ScanFile(filePath))
+	}
+	singleScanPath := NewAggregator(false, false, false, []string{"../scanner/test-files"})
+	for _, filePath := range scanner.WalkDirectory("../scanner/test-files", []scanner.IgnoreFile{}) {
+		singleScanPath.Add(scanner.ScanFile(filePath))
+	}
+
+	// Assert
+	// files in a directory that is scanned twice are counted once, for the first scan path they are in
+	assert.Equal(t, singleScanPath.Total, aggregator.Total)
+	assert.Equal(t, []RepoTotal{
+		{RepositoryId: "../scanner/test-files/js", CodeLineCount: jsTotal.CodeLineCount},
+		{RepositoryId: "../scanner/test-files", CodeLineCount: aggregator.Total.CodeLineCount - jsTotal.CodeLineCount},
+		{RepositoryId: "../scanner/test-files/js/", CodeLineCount: 0},
+	}, aggregator.RepoTotals)
+	assert.NotZero(t, jsTotal.CodeLineCount)
+	assert.Equal(t, singleScanPath.RepoTotals[0].CodeLineCount, singleScanPath.Total.CodeLineCount)
+}
@@ -3,6 +3,7 @@ package report
 import (
 	"go-cloc/logger"
 	"go-cloc/scanner"
+	"html"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,6 +16,8 @@ type FileTreeComponent struct {
 	children                []*FileTreeComponent
 	name                    string
 	CodeLineCount           int
+	MixedLineCount          int            // lines with both code and a comment
+	GeneratedCodeLineCount  int            // lines of code in generated or minified files, included in CodeLineCount
 	LanguageToCodeLineCount map[string]int // map of language to code line count, empty by default
 }
 
@@ -54,8 +57,15 @@ func createUniqueFileNameFromComponentInTree(component *FileTreeComponent) strin
 	return getFullPathNameFromTree(component, "-") + ".html"
 }
 
+// totals of the whole scan shown on the HTML pages next to the line counts of the tree
+type htmlReportTotals struct {
+	skippedFileCount       int
+	generatedCodeLineCount int  // lines of code in generated or minified files, also when they are not in the tree
+	excludeGenerated       bool // generated and minified files are left out of the tree and its line counts
+}
+
 // traverses the tree generating HTML reports for directories only
-func generateHTMLReportsForTree(component *FileTreeComponent) ([]string, []string) {
+func generateHTMLReportsForTree(component *FileTreeComponent, totals htmlReportTotals) ([]string, []string) {
 	// return empty arrays if the component has no children, since this is a file
 	if len(component.children) == 0 {
 		return []string{}, []string{}
@@ -65,13 +75,13 @@ func generateHTMLReportsForTree(component *FileTreeComponent) ([]string, []strin
 	resultingFileNames := []string{}
 	resultingFileContents := []string{}
 	fileName := createUniqueFileNameFromComponentInTree(component)
-	htmlContent := createHTMLPage(component)
+	htmlContent := createHTMLPage(component, totals)
 	resultingFileNames = append(resultingFileNames, fileName)
 	resultingFileContents = append(resultingFileContents, htmlContent)
 
 	// recursively generate HTML reports for each child
 	for _, child := range component.children {
-		fileNames, fileContents := generateHTMLReportsForTree(child)
+		fileNames, fileContents := generateHTMLReportsForTree(child, totals)
 		resultingFileNames = append(resultingFileNames, fileNames...)
 		resultingFileContents = append(resultingFileContents, fileContents...)
 	}
@@ -79,8 +89,25 @@ func generateHTMLReportsForTree(component *FileTreeComponent) ([]string, []strin
 	return resultingFileNames, resultingFileContents
 }
 
+// name of the HTML page listing the files that were not counted
+const skippedFilesHTMLFileName = "skipped-files.html"
+
+// creates the HTML page listing the files that were not counted and why
+func createSkippedFilesHTMLPage(skippedFiles []scanner.FileScanResults) string {
+	htmlContent := "<!DOCTYPE html><html lang='en'><head><meta charset='UTF-8'><style>body{font-family:Arial,sans-serif}td{padding:8px;border-bottom:1px solid #ddd}th{background-color:#f2f2f2;padding:8px}a{color:#00f;text-decoration:none}a:hover{text-decoration:underline}</style><meta name='viewport' content='width=device-width,initial-scale=1'><title>Skipped Files Report</title></head><body><h1>Skipped Files Report</h1>"
+	htmlContent += "<p><a href='./index.html'>index.html</a><span style='color:gray;'> &lAarr; Click to return</span></p>"
+	htmlContent += "<p><b>Total Skipped Files: " + strconv.Itoa(len(skippedFiles)) + "</b></p>"
+	htmlContent += "<table id='skipped-files'><thead><tr><th>File Path</th><th>Reason</th></tr></thead><tbody>"
+	for _, skippedFile := range skippedFiles {
+		htmlContent += "<tr><td>" + html.EscapeString(skippedFile.FilePath) + "</td><td>" + html.EscapeString(skippedFile.SkipReason) + "</td></tr>"
+	}
+	htmlContent += "</tbody></table>"
+	htmlContent += "</body></html>"
+	return htmlContent
+}
+
 // creates the HTML page for a given component, designed for directories
-func createHTMLPage(component *FileTreeComponent) string {
+func createHTMLPage(component *FileTreeComponent, totals htmlReportTotals) string {
 	if len(component.children) == 0 {
 		return ""
 	}
@@ -88,10 +115,25 @@ func createHTMLPage(component *FileTreeComponent) string {
 	htmlContent := "<!DOCTYPE html><html lang='en'><head><meta charset='UTF-8'><style>body{font-family:Arial,sans-serif}.table-container{display:inline-block;margin-right:20px;vertical-align:top}td{padding:8px;border-bottom:1px solid #ddd}th{background-color:#f2f2f2;padding:8px}a{color:#00f;text-decoration:none}a:hover{text-decoration:underline}.code-line-count{padding:8px;border-bottom:1px solid #ddd;text-align:right}.file,.folder{padding:10px;display:inline-block;width:20px;vertical-align:middle}</style><meta name='viewport' content='width=device-width,initial-scale=1'><title>File Tree Report</title></head><body><h1>File Tree Report</h1>"
 	htmlContent += "<p><b>Current Path:</b><a href='" + createUniqueFileNameFromComponentInTree(component.parent) + "'> '" + getFullPathNameFromTree(component, string(filepath.Separator)) + "' </a><span style='color:gray;'>&lAarr; Click to return</span></p>"
 	htmlContent += "<p><b>Total Lines of Code: " + strconv.Itoa(component.CodeLineCount) + "</b></p>"
+	htmlContent += "<p>Mixed Code and Comment Lines: " + strconv.Itoa(component.MixedLineCount) + " (counted as " + string(scanner.CountMixedLinesAs) + ")</p>"
+	// excluded generated files are not in the tree, so only the root page knows how many lines they hold
+	if !totals.excludeGenerated {
+		htmlContent += "<p>Generated or Minified Lines of Code: " + strconv.Itoa(component.GeneratedCodeLineCount) + " (included in the total)</p>"
+	} else if component.parent == nil {
+		htmlContent += "<p>Generated or Minified Lines of Code: " + strconv.Itoa(totals.generatedCodeLineCount) + " (excluded from the total)</p>"
+	}
+	// link the skipped files from the root page only
+	if component.parent == nil && totals.skippedFileCount > 0 {
+		htmlContent += "<p>Skipped Files: <a href='./" + skippedFilesHTMLFileName + "'>" + strconv.Itoa(totals.skippedFileCount) + "</a></p>"
+	}
 
 	// add file statistics
 	htmlContent += "<div class='table-container'><h2>By File</h2>"
-	htmlContent += "<table id='file-statistics'><thead><tr><th>File Name</th><th>Code Line Count</th></tr><tr><thead></thead></tr></thead><tbody>"
+	generatedColumnHeader := "<th>Generated Code Line Count</th>"
+	if totals.excludeGenerated {
+		generatedColumnHeader = ""
+	}
+	htmlContent += "<table id='file-statistics'><thead><tr><th>File Name</th><th>Code Line Count</th><th>Mixed Line Count</th>" + generatedColumnHeader + "</tr><tr><thead></thead></tr></thead><tbody>"
 	for _, child := range component.children {
 		htmlContent += "<tr><td>"
 		// file
@@ -100,11 +142,19 @@ func createHTMLPage(component *FileTreeComponent) string {
 		} else {
 			htmlContent += "<img src='folder.svg' alt='' class='folder'> <a href='./" + createUniqueFileNameFromComponentInTree(child) + "'>" + child.name + "</a>"
 		}
-		htmlContent += "</td><td class='code-line-count'>" + strconv.Itoa(child.CodeLineCount) + "</td></tr>"
+		htmlContent += "</td><td class='code-line-count'>" + strconv.Itoa(child.CodeLineCount) + "</td><td class='code-line-count'>" + strconv.Itoa(child.MixedLineCount) + "</td>"
+		if !totals.excludeGenerated {
+			htmlContent += "<td class='code-line-count'>" + strconv.Itoa(child.GeneratedCodeLineCount) + "</td>"
+		}
+		htmlContent += "</tr>"
 
 	}
 	htmlContent += "</tbody>"
-	htmlContent += "<tfoot><tr><th></th><th class='code-line-count'>" + strconv.Itoa(component.CodeLineCount) + "</th></tfoot>"
+	htmlContent += "<tfoot><tr><th></th><th class='code-line-count'>" + strconv.Itoa(component.CodeLineCount) + "</th><th class='code-line-count'>" + strconv.Itoa(component.MixedLineCount) + "</th>"
+	if !totals.excludeGenerated {
+		htmlContent += "<th class='code-line-count'>" + strconv.Itoa(component.GeneratedCodeLineCount) + "</th>"
+	}
+	htmlContent += "</tfoot>"
 	htmlContent += "</table></div>"
 	htmlContent += "</body></html>"
 
@@ -151,24 +201,34 @@ func sumUpTotalLineOfCodeInTree(component *FileTreeComponent) (int, map[string]i
 	}
 
 	sum := 0
+	sumMixedLineCount := 0
+	sumGeneratedCodeLineCount := 0
 	sumLanguageToCodeLineCount := map[string]int{}
 	for _, child := range component.children {
 		sumChildren, languageToCodeLineCount := sumUpTotalLineOfCodeInTree(child)
 		sum += sumChildren
+		sumMixedLineCount += child.MixedLineCount
+		sumGeneratedCodeLineCount += child.GeneratedCodeLineCount
 		sumLanguageToCodeLineCount = combineMapsAndSum(sumLanguageToCodeLineCount, languageToCodeLineCount)
 		logger.Debug("languageToCodeLineCount: ", languageToCodeLineCount)
 	}
 	logger.Debug("Len of children: ", len(component.children))
 	logger.Debug("sumLanguageToCodeLineCount: ", sumLanguageToCodeLineCount)
 	component.CodeLineCount = sum
+	component.MixedLineCount = sumMixedLineCount
+	component.GeneratedCodeLineCount = sumGeneratedCodeLineCount
 	component.LanguageToCodeLineCount = sumLanguageToCodeLineCount
 	return sum, sumLanguageToCodeLineCount
 }
 
 // helper function to sort a list of FileTreeComponents by their CodeLineCount
 func sortComponentsByCodeLineCount(components []*FileTreeComponent) []*FileTreeComponent {
+	// ties are sorted by name, so the order does not depend on the order the files were scanned in
 	sort.Slice(components, func(i, j int) bool {
-		return components[i].CodeLineCount > components[j].CodeLineCount
+		if components[i].CodeLineCount != components[j].CodeLineCount {
+			return components[i].CodeLineCount > components[j].CodeLineCount
+		}
+		return components[i].name < components[j].name
 	})
 	return components
 }
@@ -180,8 +240,12 @@ func sortKeysByValueInMap(m map[string]int) []Pair {
 		pairs = append(pairs, Pair{Key: k, Value: v})
 	}
 
+	// ties are sorted by key so the order is stable between runs
 	sort.Slice(pairs, func(i, j int) bool {
-		return pairs[i].Value > pairs[j].Value
+		if pairs[i].Value != pairs[j].Value {
+			return pairs[i].Value > pairs[j].Value
+		}
+		return pairs[i].Key < pairs[j].Key
 	})
 
 	return pairs
@@ -232,54 +296,80 @@ func findChild(parent *FileTreeComponent, childName string) *FileTreeComponent {
 	return nil
 }
 
-func createTreeFromScanResults(fileScanResults []scanner.FileScanResults) *FileTreeComponent {
-
-	// create root node of the tree
-	root := &FileTreeComponent{
+// creates an empty tree to add files to
+func newFileTree() *FileTreeComponent {
+	return &FileTreeComponent{
 		name:                    "",
 		parent:                  nil,
 		children:                []*FileTreeComponent{},
 		CodeLineCount:           0,
 		LanguageToCodeLineCount: map[string]int{},
 	}
+}
 
-	// create tree structure based on the fileScanResults
-	for _, result := range fileScanResults {
-		previousComponent := root
-		filePathComponents := ParseFileStructure(result.FilePath, string(filepath.Separator))
-		filePathComponentsLastIndex := len(filePathComponents) - 1
-		for j, component := range filePathComponents {
-			// only create a component if it doesn't already exist in the tree
-			foundChild := findChild(previousComponent, component)
-			if foundChild != nil {
-				previousComponent = foundChild
-			} else {
-				newChild := &FileTreeComponent{
-					name:                    component,
-					children:                []*FileTreeComponent{},
-					CodeLineCount:           0,
-					LanguageToCodeLineCount: map[string]int{},
+// adds a file to the tree, creating the directories on its path that are not in the tree yet
+func addFileToTree(root *FileTreeComponent, result scanner.FileScanResults) {
+	previousComponent := root
+	filePathComponents := ParseFileStructure(result.FilePath, string(filepath.Separator))
+	filePathComponentsLastIndex := len(filePathComponents) - 1
+	for j, component := range filePathComponents {
+		// only create a component if it doesn't already exist in the tree
+		foundChild := findChild(previousComponent, component)
+		if foundChild != nil {
+			previousComponent = foundChild
+		} else {
+			newChild := &FileTreeComponent{
+				name:                    component,
+				children:                []*FileTreeComponent{},
+				CodeLineCount:           0,
+				LanguageToCodeLineCount: map[string]int{},
+			}
+			logger.Debug("newChild: ", component)
+			// leaf node
+			if j == filePathComponentsLastIndex {
+				newChild.CodeLineCount = result.CodeLineCount
+				newChild.MixedLineCount = result.MixedLineCount
+				if result.IsGenerated {
+					newChild.GeneratedCodeLineCount = result.CodeLineCount
 				}
-				logger.Debug("newChild: ", component)
-				// leaf node
-				if j == filePathComponentsLastIndex {
-					newChild.CodeLineCount = result.CodeLineCount
+				// files with embedded languages, ex: .vue, are split up by language
+				if result.LanguageToCodeLineCount != nil {
+					for languageName, codeLineCount := range result.LanguageToCodeLineCount {
+						newChild.LanguageToCodeLineCount[languageName] = codeLineCount
+					}
+				} else {
 					newChild.LanguageToCodeLineCount[result.LanguageName] = result.CodeLineCount
 				}
-				addChild(previousComponent, newChild)
-				previousComponent = newChild
 			}
+			addChild(previousComponent, newChild)
+			previousComponent = newChild
 		}
 	}
+}
 
+func createTreeFromScanResults(fileScanResults []scanner.FileScanResults) *FileTreeComponent {
+	root := newFileTree()
+	for _, result := range fileScanResults {
+		addFileToTree(root, result)
+	}
 	return root
 }
 
 // Creates HTML reports to visualize the LoC in the same file structure as was scanned. Helpful for identifying large directories.
-func GenerateHTMLReports(fileScanResults []scanner.FileScanResults) ([]string, []string) {
-
-	root := createTreeFromScanResults(fileScanResults)
+// Files that were skipped are listed on a separate page linked from index.html, generated and minified files are included in the total
+func GenerateHTMLReports(fileScanResults []scanner.FileScanResults, skippedFiles []scanner.FileScanResults) ([]string, []string) {
+	generatedTotal := scanner.FileScanResults{}
+	for _, result := range fileScanResults {
+		if result.IsGenerated {
+			addToTotal(&generatedTotal, result)
+		}
+	}
+	return GenerateHTMLReportsFromTree(createTreeFromScanResults(fileScanResults), skippedFiles, generatedTotal, false)
+}
 
+// GenerateHTMLReportsFromTree creates the HTML reports from a tree which was built up while scanning, see Aggregator
+// generatedTotal is the total of the generated and minified files, excludeGenerated is set when they were left out of the tree
+func GenerateHTMLReportsFromTree(root *FileTreeComponent, skippedFiles []scanner.FileScanResults, generatedTotal scanner.FileScanResults, excludeGenerated bool) ([]string, []string) {
 	// calculate total LOC in the tree
 	sumUpTotalLineOfCodeInTree(root)
 
@@ -287,7 +377,19 @@ func GenerateHTMLReports(fileScanResults []scanner.FileScanResults) ([]string, [
 	sortTreeByCodeLineCount(root)
 
 	// generate HTML reports for each file in the tree
-	return generateHTMLReportsForTree(root)
+	totals := htmlReportTotals{
+		skippedFileCount:       len(skippedFiles),
+		generatedCodeLineCount: generatedTotal.CodeLineCount,
+		excludeGenerated:       excludeGenerated,
+	}
+	fileNames, fileContents := generateHTMLReportsForTree(root, totals)
+
+	// list the skipped files on their own page
+	if len(skippedFiles) > 0 {
+		fileNames = append(fileNames, skippedFilesHTMLFileName)
+		fileContents = append(fileContents, createSkippedFilesHTMLPage(skippedFiles))
+	}
+	return fileNames, fileContents
 }
 
 // simple function to write SVGs to files for the HTML reports to use
@@ -59,3 +59,70 @@ func Test_file_tree_createTreeFromScanResults(t *testing.T) {
 	assert.Equal(t, "file3.py", file3.name)
 
 }
+
+func Test_file_tree_GenerateHTMLReports_skipped_files(t *testing.T) {
+	fileScanResults := []scanner.FileScanResults{
+		{FilePath: "/home/file1.go", LanguageName: "go", CodeLineCount: 10},
+	}
+	skippedFiles := []scanner.FileScanResults{
+		{FilePath: "/home/logo.js", LanguageName: "JavaScript", SkipReason: "binary: PNG signature"},
+	}
+	fileNames, fileContents := GenerateHTMLReports(fileScanResults, skippedFiles)
+
+	// Assert
+	assert.Equal(t, "index.html", fileNames[0])
+	assert.Contains(t, fileContents[0], "href='./skipped-files.html'")
+	assert.Equal(t, "skipped-files.html", fileNames[len(fileNames)-1])
+	assert.Contains(t, fileContents[len(fileContents)-1], "/home/logo.js")
+	assert.Contains(t, fileContents[len(fileContents)-1], "binary: PNG signature")
+}
+
+func Test_file_tree_sum_generated_code(t *testing.T) {
+	fileScanResults := []scanner.FileScanResults{
+		{FilePath: "/home/file1.go", LanguageName: "go", CodeLineCount: 10},
+		{FilePath: "/home/file1.pb.go", LanguageName: "go", CodeLineCount: 200, IsGenerated: true},
+		{FilePath: "/test/app.min.js", LanguageName: "javascript", CodeLineCount: 1, IsGenerated: true},
+	}
+	root := createTreeFromScanResults(fileScanResults)
+	sumUpTotalLineOfCodeInTree(root)
+
+	// Assert
+	assert.Equal(t, 211, root.CodeLineCount)
+	assert.Equal(t, 201, root.GeneratedCodeLineCount)
+	assert.Equal(t, 200, root.children[0].GeneratedCodeLineCount)
+}
+
+func Test_file_tree_GenerateHTMLReportsFromTree_generated_code(t *testing.T) {
+	fileScanResults := []scanner.FileScanResults{
+		{FilePath: "/home/file1.go", LanguageName: "go", CodeLineCount: 10},
+		{FilePath: "/home/file1.pb.go", LanguageName: "go", CodeLineCount: 200, IsGenerated: true},
+	}
+	generatedTotal := scanner.FileScanResults{CodeLineCount: 200}
+	_, includedContents := GenerateHTMLReports(fileScanResults, []scanner.FileScanResults{})
+	// excluded generated files are not added to the tree, see Aggregator
+	_, excludedContents := GenerateHTMLReportsFromTree(createTreeFromScanResults(fileScanResults[:1]), []scanner.FileScanResults{}, generatedTotal, true)
+
+	// Assert
+	assert.Contains(t, includedContents[0], "<b>Total Lines of Code: 210</b>")
+	assert.Contains(t, includedContents[0], "Generated or Minified Lines of Code: 200 (included in the total)")
+	assert.Contains(t, includedContents[1], "Generated or Minified Lines of Code: 200 (included in the total)")
+	assert.Contains(t, includedContents[0], "<th>Generated Code Line Count</th>")
+	assert.Contains(t, excludedContents[0], "<b>Total Lines of Code: 10</b>")
+	assert.Contains(t, excludedContents[0], "Generated or Minified Lines of Code: 200 (excluded from the total)")
+	assert.NotContains(t, excludedContents[1], "Generated or Minified Lines of Code")
+	assert.NotContains(t, excludedContents[0], "<th>Generated Code Line Count</th>")
+}
+
+func Test_file_tree_embedded_languages(t *testing.T) {
+	fileScanResults := []scanner.FileScanResults{
+		{FilePath: "/home/app.vue", LanguageName: "Vue", CodeLineCount: 30, LanguageToCodeLineCount: map[string]int{"Vue": 10, "HTML": 12, "TypeScript": 8}},
+		{FilePath: "/home/main.ts", LanguageName: "TypeScript", CodeLineCount: 5},
+	}
+	root := createTreeFromScanResults(fileScanResults)
+	sumUpTotalLineOfCodeInTree(root)
+
+	// Assert
+	assert.Equal(t, 35, root.CodeLineCount)
+	assert.Equal(t, map[string]int{"Vue": 10, "HTML": 12, "TypeScript": 13}, root.LanguageToCodeLineCount)
+	assert.Equal(t, []Pair{{"TypeScript", 13}, {"HTML", 12}, {"Vue", 10}}, sortKeysByValueInMap(root.LanguageToCodeLineCount))
+}
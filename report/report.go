@@ -5,6 +5,7 @@ import (
 	"go-cloc/logger"
 	"go-cloc/scanner"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,19 +17,27 @@ type RepoTotal struct {
 }
 
 // SortFileScanResults sorts the file scan results by CodeLineCount in descending order
+// Ties are sorted by FilePath, so the order does not depend on the order the files were scanned in
 func SortFileScanResults(fileScanResultsArr []scanner.FileScanResults) []scanner.FileScanResults {
 	// Sort by CodeLineCount desc
 	sort.Slice(fileScanResultsArr, func(a, b int) bool {
-		return fileScanResultsArr[a].CodeLineCount > fileScanResultsArr[b].CodeLineCount
+		if fileScanResultsArr[a].CodeLineCount != fileScanResultsArr[b].CodeLineCount {
+			return fileScanResultsArr[a].CodeLineCount > fileScanResultsArr[b].CodeLineCount
+		}
+		return fileScanResultsArr[a].FilePath < fileScanResultsArr[b].FilePath
 	})
 	return fileScanResultsArr
 }
 
 // SortRepoTotalResults sorts the repo total results by CodeLineCount in descending order
+// Ties are sorted by RepositoryId
 func SortRepoTotalResults(repoTotalArr []RepoTotal) []RepoTotal {
 	// Sort by CodeLineCount desc
 	sort.Slice(repoTotalArr, func(a, b int) bool {
-		return repoTotalArr[a].CodeLineCount > repoTotalArr[b].CodeLineCount
+		if repoTotalArr[a].CodeLineCount != repoTotalArr[b].CodeLineCount {
+			return repoTotalArr[a].CodeLineCount > repoTotalArr[b].CodeLineCount
+		}
+		return repoTotalArr[a].RepositoryId < repoTotalArr[b].RepositoryId
 	})
 	return repoTotalArr
 }
@@ -39,29 +48,97 @@ func CalculateTotalLineOfCode(fileScanResultsArr []scanner.FileScanResults) scan
 
 	totalResults.FilePath = "total"
 	for _, results := range fileScanResultsArr {
-		totalResults.BlankLineCount += results.BlankLineCount
-		totalResults.CommentsLineCount += results.CommentsLineCount
-		totalResults.CodeLineCount += results.CodeLineCount
-		totalResults.TotalLines += results.TotalLines
+		addToTotal(&totalResults, results)
 	}
 	return totalResults
 }
 
+// adds the line counts of a file to a total
+func addToTotal(totalResults *scanner.FileScanResults, results scanner.FileScanResults) {
+	totalResults.BlankLineCount += results.BlankLineCount
+	totalResults.CommentsLineCount += results.CommentsLineCount
+	totalResults.CodeLineCount += results.CodeLineCount
+	totalResults.MixedLineCount += results.MixedLineCount
+	totalResults.DataLineCount += results.DataLineCount
+	totalResults.TotalLines += results.TotalLines
+}
+
+// adds the lines of code of a file to the lines of code by language
+// Files with embedded languages, ex: .vue, add to each language they contain
+func addToLanguageToCodeLineCount(languageToCodeLineCount map[string]int, results scanner.FileScanResults) {
+	if results.LanguageToCodeLineCount == nil {
+		languageToCodeLineCount[results.LanguageName] += results.CodeLineCount
+		return
+	}
+	for languageName, codeLineCount := range results.LanguageToCodeLineCount {
+		languageToCodeLineCount[languageName] += codeLineCount
+	}
+}
+
 // OutputCSV writes the results of the scan to a CSV file
 // Returns the total number of lines of code for all files scanned
-func ConvertFileResultsIntoRecords(fileScanResultsArr []scanner.FileScanResults, totalResults scanner.FileScanResults) [][]string {
+// The generated row sums up the generated and minified files, which may or may not be part of the total
+func ConvertFileResultsIntoRecords(fileScanResultsArr []scanner.FileScanResults, totalResults scanner.FileScanResults, generatedResults scanner.FileScanResults) [][]string {
 	// Create CSV information
 	records := [][]string{
-		{"filePath", "languageName", "blank", "comment", "code"},
+		{"filePath", "languageName", "blank", "comment", "code", "mixed", "detectionRule", "generated", "encoding", "lineEnding", "lines", "realPath"},
 	}
 
 	for _, results := range fileScanResultsArr {
-		row := []string{results.FilePath, results.LanguageName, strconv.Itoa(results.BlankLineCount), strconv.Itoa(results.CommentsLineCount), strconv.Itoa(results.CodeLineCount)}
+		row := []string{results.FilePath, results.LanguageName, strconv.Itoa(results.BlankLineCount), strconv.Itoa(results.CommentsLineCount), strconv.Itoa(results.CodeLineCount), strconv.Itoa(results.MixedLineCount), results.DetectionRule, results.GeneratedReason, string(results.Encoding), string(results.LineEnding), strconv.Itoa(results.TotalLines), results.RealPath}
 		records = append(records, row)
 	}
 	// Append Total Row
-	totalRow := []string{"total", "", strconv.Itoa(totalResults.BlankLineCount), strconv.Itoa(totalResults.CommentsLineCount), strconv.Itoa(totalResults.CodeLineCount)}
+	totalRow := []string{"total", "", strconv.Itoa(totalResults.BlankLineCount), strconv.Itoa(totalResults.CommentsLineCount), strconv.Itoa(totalResults.CodeLineCount), strconv.Itoa(totalResults.MixedLineCount), "", "", "", "", strconv.Itoa(totalResults.TotalLines), ""}
 	records = append(records, totalRow)
+	// Append Generated Row
+	generatedRow := []string{"generated", "", strconv.Itoa(generatedResults.BlankLineCount), strconv.Itoa(generatedResults.CommentsLineCount), strconv.Itoa(generatedResults.CodeLineCount), strconv.Itoa(generatedResults.MixedLineCount), "", "", "", "", strconv.Itoa(generatedResults.TotalLines), ""}
+	records = append(records, generatedRow)
+	return records
+}
+
+// ConvertLanguageTotalsIntoRecords creates the CSV section with the lines of code by language, sorted by lines of code in descending order
+// The section is separated from the scan results by an empty row
+func ConvertLanguageTotalsIntoRecords(languageToCodeLineCount map[string]int) [][]string {
+	records := [][]string{
+		{},
+		{"language", "code"},
+	}
+	for _, pair := range sortKeysByValueInMap(languageToCodeLineCount) {
+		records = append(records, []string{pair.Key, strconv.Itoa(pair.Value)})
+	}
+	return records
+}
+
+// ConvertRepoTotalsIntoRecords creates the CSV section with the lines of code by scanned directory, sorted by lines of code in descending order
+// The section is separated from the scan results by an empty row, it is left out when a single directory was scanned
+func ConvertRepoTotalsIntoRecords(repoTotals []RepoTotal) [][]string {
+	if len(repoTotals) < 2 {
+		return [][]string{}
+	}
+	records := [][]string{
+		{},
+		{"scanPath", "code"},
+	}
+	for _, repoTotal := range SortRepoTotalResults(slices.Clone(repoTotals)) {
+		records = append(records, []string{repoTotal.RepositoryId, strconv.Itoa(repoTotal.CodeLineCount)})
+	}
+	return records
+}
+
+// ConvertSkippedFilesIntoRecords creates the CSV section listing the files that were skipped and why
+// The section is separated from the scan results by an empty row
+func ConvertSkippedFilesIntoRecords(skippedFiles []scanner.FileScanResults) [][]string {
+	if len(skippedFiles) == 0 {
+		return [][]string{}
+	}
+	records := [][]string{
+		{},
+		{"skippedFilePath", "reason"},
+	}
+	for _, results := range skippedFiles {
+		records = append(records, []string{results.FilePath, results.SkipReason})
+	}
 	return records
 }
 
@@ -101,14 +178,72 @@ func PrintCsv(records [][]string) {
 	}
 }
 
-func PrintResultsToCommandLine(codeLineCount int, commentsLineCount int, blankLineCount int) {
+func PrintResultsToCommandLine(codeLineCount int, commentsLineCount int, blankLineCount int, mixedLineCount int) {
+	totalLineCount := codeLineCount + blankLineCount + commentsLineCount
+	// mixed lines were added to both the code and comment counts, only count them once in the total
+	if scanner.CountMixedLinesAs == scanner.MixedAsBoth {
+		totalLineCount -= mixedLineCount
+	}
 	column1Arr := formatStringsForColumn([]string{"Code", strconv.Itoa(codeLineCount)})
 	column2Arr := formatStringsForColumn([]string{"Blank lines", strconv.Itoa(blankLineCount)})
 	column3Arr := formatStringsForColumn([]string{"Comments", strconv.Itoa(commentsLineCount)})
-	column4Arr := formatStringsForColumn([]string{"Total", strconv.Itoa(codeLineCount + blankLineCount + commentsLineCount)})
+	column4Arr := formatStringsForColumn([]string{"Mixed (counted as " + string(scanner.CountMixedLinesAs) + ")", strconv.Itoa(mixedLineCount)})
+	column5Arr := formatStringsForColumn([]string{"Total", strconv.Itoa(totalLineCount)})
 
 	for i := range 2 {
-		logger.Info(column1Arr[i], "\t", column2Arr[i], "\t", column3Arr[i], "\t", column4Arr[i])
+		logger.Info(column1Arr[i], "\t", column2Arr[i], "\t", column3Arr[i], "\t", column4Arr[i], "\t", column5Arr[i])
+	}
+}
+
+// PrintRepoTotalsToCommandLine logs the lines of code of each scanned directory when more than one was scanned
+func PrintRepoTotalsToCommandLine(repoTotals []RepoTotal) {
+	if len(repoTotals) < 2 {
+		return
+	}
+	sortedRepoTotals := SortRepoTotalResults(slices.Clone(repoTotals))
+	repositoryIds := []string{"Path"}
+	codeLineCounts := []string{"Code"}
+	for _, repoTotal := range sortedRepoTotals {
+		repositoryIds = append(repositoryIds, repoTotal.RepositoryId)
+		codeLineCounts = append(codeLineCounts, strconv.Itoa(repoTotal.CodeLineCount))
+	}
+	column1Arr := formatStringsForColumn(repositoryIds)
+	column2Arr := formatStringsForColumn(codeLineCounts)
+	for i := range column1Arr {
+		logger.Info(column1Arr[i], "\t", column2Arr[i])
+	}
+}
+
+// PrintSkippedFilesToCommandLine logs how many files were skipped for each reason, each skipped file is logged in debug mode
+func PrintSkippedFilesToCommandLine(skippedFiles []scanner.FileScanResults) {
+	if len(skippedFiles) == 0 {
+		return
+	}
+	reasonToCount := map[string]int{}
+	for _, results := range skippedFiles {
+		reasonToCount[results.SkipReason]++
+		logger.Debug("Skipped ", results.FilePath, ": ", results.SkipReason)
+	}
+	logger.Info("Skipped ", len(skippedFiles), " files")
+	reasons := make([]string, 0, len(reasonToCount))
+	for reason := range reasonToCount {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		logger.Info("\t", reasonToCount[reason], " ", reason)
+	}
+}
+
+// PrintGeneratedFilesToCommandLine logs the lines of code found in generated and minified files
+func PrintGeneratedFilesToCommandLine(generatedResults scanner.FileScanResults, generatedFileCount int, excludeGenerated bool) {
+	if generatedFileCount == 0 {
+		return
+	}
+	if excludeGenerated {
+		logger.Info("Generated or minified code: ", generatedResults.CodeLineCount, " lines in ", generatedFileCount, " files, excluded from the total")
+	} else {
+		logger.Info("Generated or minified code: ", generatedResults.CodeLineCount, " lines in ", generatedFileCount, " files, included in the total. Use --exclude-generated to exclude them")
 	}
 }
 
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"go-cloc/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_report_SortFileScanResults_ties_by_path(t *testing.T) {
+	fileScanResults := []scanner.FileScanResults{
+		{FilePath: "/b.go", CodeLineCount: 10},
+		{FilePath: "/c.go", CodeLineCount: 20},
+		{FilePath: "/a.go", CodeLineCount: 10},
+	}
+	sorted := SortFileScanResults(fileScanResults)
+
+	// Assert
+	assert.Equal(t, "/c.go", sorted[0].FilePath)
+	assert.Equal(t, "/a.go", sorted[1].FilePath)
+	assert.Equal(t, "/b.go", sorted[2].FilePath)
+}
+
+func Test_report_SortRepoTotalResults_ties_by_repository(t *testing.T) {
+	repoTotals := []RepoTotal{
+		{RepositoryId: "svc-b", CodeLineCount: 10},
+		{RepositoryId: "lib/shared", CodeLineCount: 20},
+		{RepositoryId: "svc-a", CodeLineCount: 10},
+	}
+
+	result := SortRepoTotalResults(repoTotals)
+
+	// Assert
+	assert.Equal(t, []RepoTotal{
+		{RepositoryId: "lib/shared", CodeLineCount: 20},
+		{RepositoryId: "svc-a", CodeLineCount: 10},
+		{RepositoryId: "svc-b", CodeLineCount: 10},
+	}, result)
+}
+
+func Test_report_ConvertRepoTotalsIntoRecords(t *testing.T) {
+	repoTotals := []RepoTotal{
+		{RepositoryId: "svc-a", CodeLineCount: 10},
+		{RepositoryId: "svc-b", CodeLineCount: 20},
+	}
+
+	records := ConvertRepoTotalsIntoRecords(repoTotals)
+
+	// Assert
+	assert.Equal(t, [][]string{{}, {"scanPath", "code"}, {"svc-b", "20"}, {"svc-a", "10"}}, records)
+	// the totals keep the order the paths were given in
+	assert.Equal(t, "svc-a", repoTotals[0].RepositoryId)
+	assert.Empty(t, ConvertRepoTotalsIntoRecords(repoTotals[:1]))
+}
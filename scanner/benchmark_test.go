@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"go-cloc/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixtures that are large enough for the throughput not to be dominated by opening the file
+var benchmarkFiles = []string{
+	"test-files/c/hard.c",
+	"test-files/cpp/evil.cpp",
+	"test-files/cpp/hard.cpp",
+	"test-files/misc/massive-line.yaml",
+	"test-files/cobol/payroll.cbl",
+}
+
+// reads all lines of the content and analyzes them the way ScanFile does
+func analyzeAllLines(lineReader *LineReader, languageInfo LanguageInfo) {
+	state := LineState{}
+	for {
+		lineBytes, err := lineReader.ReadLineBytes()
+		if err != nil {
+			return
+		}
+		lineBytes, _ = applyColumnRules(lineBytes, &languageInfo)
+		_, state = AnalyzeLine(strings.TrimSpace(viewAsString(lineBytes)), languageInfo, state)
+	}
+}
+
+func Test_scanner_reading_and_analyzing_lines_does_not_allocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not representative with the race detector")
+	}
+	for _, filePath := range benchmarkFiles {
+		t.Run(filepath.Base(filePath), func(t *testing.T) {
+			content, err := os.ReadFile(filePath)
+			assert.Nil(t, err)
+			_, languageInfo, _, _ := DetectLanguage(filePath)
+			source := bytes.NewReader(content)
+			reader := bufio.NewReaderSize(source, ReadBufferSize)
+			lineReader := NewLineReader(reader)
+
+			// the first run grows the line buffer of the reader to the longest line, later runs reuse it
+			allocs := testing.AllocsPerRun(5, func() {
+				source.Reset(content)
+				reader.Reset(source)
+				analyzeAllLines(lineReader, languageInfo)
+			})
+
+			// Assert
+			assert.Equal(t, 0.0, allocs)
+		})
+	}
+}
+
+func Test_scanner_ScanFile_allocations_do_not_grow_with_lines(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not representative with the race detector")
+	}
+	logLevel := logger.GetLogLevel()
+	logger.SetLogLevel(logger.INFO)
+	defer logger.SetLogLevel(logLevel)
+	smallFileAllocs := testing.AllocsPerRun(5, func() { ScanFile("test-files/c/trailing-block-comment.c") })
+	largeFileAllocs := testing.AllocsPerRun(5, func() { ScanFile("test-files/cpp/evil.cpp") })
+
+	// Assert
+	// evil.cpp has over 11000 lines, only a few allocations may depend on the file, ex: the language map
+	assert.Less(t, largeFileAllocs, smallFileAllocs+10)
+}
+
+func BenchmarkScanFile(b *testing.B) {
+	logLevel := logger.GetLogLevel()
+	logger.SetLogLevel(logger.INFO)
+	defer logger.SetLogLevel(logLevel)
+	for _, filePath := range benchmarkFiles {
+		b.Run(filepath.Base(filePath), func(b *testing.B) {
+			info, err := os.Stat(filePath)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(info.Size())
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				ScanFile(filePath)
+			}
+		})
+	}
+}
+
+// scans every fixture, most of them are small so this tracks the cost per file rather than per line
+func BenchmarkScanFile_all_fixtures(b *testing.B) {
+	logLevel := logger.GetLogLevel()
+	logger.SetLogLevel(logger.INFO)
+	defer logger.SetLogLevel(logLevel)
+	filePaths := WalkDirectory("test-files", []IgnoreFile{})
+	size := int64(0)
+	for _, filePath := range filePaths {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			b.Fatal(err)
+		}
+		size += info.Size()
+	}
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		for _, filePath := range filePaths {
+			ScanFile(filePath)
+		}
+	}
+}
+
+func BenchmarkLineReader(b *testing.B) {
+	content, err := os.ReadFile("test-files/cpp/evil.cpp")
+	if err != nil {
+		b.Fatal(err)
+	}
+	source := bytes.NewReader(content)
+	reader := bufio.NewReaderSize(source, ReadBufferSize)
+	lineReader := NewLineReader(reader)
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		source.Reset(content)
+		reader.Reset(source)
+		for {
+			if _, err := lineReader.ReadLineBytes(); err == io.EOF {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkAnalyzeLine(b *testing.B) {
+	for _, filePath := range benchmarkFiles {
+		b.Run(filepath.Base(filePath), func(b *testing.B) {
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, languageInfo, _, _ := DetectLanguage(filePath)
+			lines := strings.Split(string(content), "\n")
+			b.SetBytes(int64(len(content)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				state := LineState{}
+				for _, line := range lines {
+					_, state = AnalyzeLine(strings.TrimSpace(line), languageInfo, state)
+				}
+			}
+		})
+	}
+}
@@ -7,258 +7,1271 @@ import (
 	"go-cloc/logger"
 	"io"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 )
 
 type LanguageInfo struct {
-	LineComments      []string   `json:"LineComments"`
-	MultiLineComments [][]string `json:"MultiLineComments"`
-	Extensions        []string   `json:"Extensions"`
-	FileNames         []string   `json:"FileNames"`
+	LineComments              []string          `json:"LineComments"`
+	LineCommentExceptions     []string          `json:"LineCommentExceptions"` // tokens which begin with one of the LineComments but are code, ex: #[ opens a PHP attribute
+	WordStartLineComments     []string          `json:"WordStartLineComments"` // LineComments which only start a comment at the start of a line, after whitespace or after ;, ex: $# is code in Shell
+	MultiLineComments         [][]string        `json:"MultiLineComments"`
+	NestedComments            []string          `json:"NestedComments"`            // opening tokens of MultiLineComments pairs which may be nested, ex: /* /* */ */
+	StringDelimiters          [][]string        `json:"StringDelimiters"`          // string and char literals, these end with the line
+	MultiLineStringDelimiters [][]string        `json:"MultiLineStringDelimiters"` // string and template literals which may span lines
+	EscapeCharacters          []string          `json:"EscapeCharacters"`          // escapes the character after it within a literal
+	StringPrefixes            []string          `json:"StringPrefixes"`            // letters which may precede a string delimiter, ex: r"raw"
+	DocStringDelimiters       []string          `json:"DocStringDelimiters"`       // MultiLineStringDelimiters which are documentation when they start a statement
+	LabelDelimiters           []string          `json:"LabelDelimiters"`           // StringDelimiters which start a label or lifetime when followed by a word rather than a single character, ex: 'a in Rust
+	RegexDelimiters           []string          `json:"RegexDelimiters"`           // open and close a regular expression literal where an operand is expected, ex: /\/*/ in JavaScript
+	Extensions                []string          `json:"Extensions"`
+	FileNames                 []string          `json:"FileNames"`
+	Interpreters              []string          `json:"Interpreters"`      // shebang interpreters for files without a suffix, versions are ignored, ex: python matches python3.11
+	Priority                  int               `json:"Priority"`          // when languages share an extension and no heuristic matches, the highest priority wins
+	Heuristics                []string          `json:"Heuristics"`        // regular expressions over the start of a file, a match picks this language for a shared extension
+	EmbeddedLanguages         map[string]string `json:"EmbeddedLanguages"` // languages of blocks within a file by tag, or by tag:lang for a lang or type attribute, ex: "script:ts": "TypeScript"
+	ColumnComments            []ColumnComment   `json:"ColumnComments"`    // fixed format comment indicators, checked before the line is trimmed
+	IgnoredColumns            [][]int           `json:"IgnoredColumns"`    // inclusive ranges of columns, counted from 1, that are not part of the code, ex: sequence numbers in [1, 6]
 }
 
+// ColumnComment marks a line as a comment when one of the Indicators starts at the Column, counted from 1, ex: * in column 7 of COBOL
+type ColumnComment struct {
+	Column     int      `json:"Column"`
+	Indicators []string `json:"Indicators"`
+}
+
+// number of bytes at the start of a file searched by Heuristics
+const HeuristicsSampleBytes = 16 * 1024
+
+// compiled Heuristics, keyed by the regular expression
+var heuristicsCache = sync.Map{}
+
 var Languages = map[string]LanguageInfo{
 	"ActionScript": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".as"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".as"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  1,
+		Heuristics:                []string{`\bimport\s+flash\.`},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Abap": {
-		LineComments:      []string{"\""},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".abap", ".ab4", ".flow"},
-		FileNames:         []string{},
+		LineComments:              []string{"\""},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}, {"`", "`"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".abap", ".ab4", ".flow"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Apex": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".cls", ".trigger"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".cls", ".trigger"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"C": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".c"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".c"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"C Header": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".h"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".h"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  1,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"C++": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".cpp", ".cc", ".cxx", ".c++"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".cpp", ".cc", ".cxx", ".c++"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"C++ Header": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".hh", ".hpp", ".hxx", ".h++", ".ipp"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".h", ".hh", ".hpp", ".hxx", ".h++", ".ipp"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{`(?m)^\s*(class|namespace|template)\b`, `\bstd::`, `(?m)^\s*#include\s*<(iostream|string|vector|map|memory)>`, `(?m)^\s*(public|private|protected):`},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"COBOL": {
-		LineComments:      []string{"*", "/"},
-		MultiLineComments: [][]string{},
-		Extensions:        []string{".cbl", ".ccp", ".cob", ".cobol", ".cpy"},
-		FileNames:         []string{},
+		LineComments:              []string{"*>"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".cbl", ".ccp", ".cob", ".cobol", ".cpy"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{{Column: 7, Indicators: []string{"*", "/"}}},
+		IgnoredColumns:            [][]int{{1, 6}, {73, 80}},
 	},
 	"C#": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".cs"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".cs"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"CSS": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".css"},
-		FileNames:         []string{},
+		LineComments:              []string{},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".css"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"D": {
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}, {"/+", "+/"}},
+		NestedComments:            []string{"/+"},
+		StringDelimiters:          [][]string{{"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"\"", "\""}, {"`", "`"}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".d"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Fortran": {
+		LineComments:              []string{"!"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}, {"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".f90", ".f95", ".f03", ".f08"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Fortran 77": {
+		LineComments:              []string{"!"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}, {"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".f", ".for", ".ftn", ".f77"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{{Column: 1, Indicators: []string{"C", "c", "*", "!"}}},
+		IgnoredColumns:            [][]int{{73, 80}},
 	},
 	"Golang": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".go"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"`", "`"}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".go"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"HTML": {
-		LineComments:      []string{},
-		MultiLineComments: [][]string{{"<!--", "-->"}},
-		Extensions:        []string{".html", ".htm", ".cshtml", ".vbhtml", ".aspx", ".ascx", ".rhtml", ".erb", ".shtml", ".shtm", ".cmp"},
-		FileNames:         []string{},
+		LineComments:              []string{},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"<!--", "-->"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".html", ".htm", ".cshtml", ".vbhtml", ".aspx", ".ascx", ".rhtml", ".erb", ".shtml", ".shtm", ".cmp"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages: map[string]string{
+			"script":                 "JavaScript",
+			"script:text/typescript": "TypeScript",
+			"style":                  "CSS",
+			"style:text/scss":        "Scss",
+			"style:text/less":        "Less",
+		},
+		ColumnComments: []ColumnComment{},
+		IgnoredColumns: [][]int{},
+	},
+	"Haskell": {
+		LineComments:              []string{"--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"{-", "-}"}},
+		NestedComments:            []string{"{-"},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{{"\"", "\""}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".hs"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Java": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".java", ".jav"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"\"\"\"", "\"\"\""}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".java", ".jav"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"JavaScript": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".js", ".jsx", ".jsp", ".jspx", ".jspf", ".mjs"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"`", "`"}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{"/"},
+		Extensions:                []string{".js", ".jsx", ".jsp", ".jspx", ".jspf", ".mjs"},
+		FileNames:                 []string{},
+		Interpreters:              []string{"node", "nodejs"},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Jupyter Notebook": {
+		LineComments:              []string{},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".ipynb"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Kotlin": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".kt", ".kts"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{"/*"},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"\"\"\"", "\"\"\""}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".kt", ".kts"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Less": {
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".less"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Flex": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".as"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".as"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{`\bimport\s+mx\.`, `<mx:`},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"OCaml": {
+		LineComments:              []string{},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"(*", "*)"}},
+		NestedComments:            []string{"(*"},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{{"\"", "\""}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".ml", ".mli"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"PHP": {
-		LineComments:      []string{"//", "#"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".php", ".php3", ".php4", ".php5", ".phtml", ".inc"},
-		FileNames:         []string{},
+		LineComments:              []string{"//", "#"},
+		LineCommentExceptions:     []string{"#["},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".php", ".php3", ".php4", ".php5", ".phtml", ".inc"},
+		FileNames:                 []string{},
+		Interpreters:              []string{"php"},
+		Priority:                  1,
+		Heuristics:                []string{`<\?php`, `(?m)^\s*\$[A-Za-z_]\w*\s*=`},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"MATLAB": {
+		LineComments:              []string{"%"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"%{", "%}"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".m"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{`(?m)^\s*function\b`, `(?m)^\s*%`, `(?m)^\s*end\s*$`},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Objective-C": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".m"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".m", ".h"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  1,
+		Heuristics:                []string{`(?m)^\s*@(interface|implementation|protocol|end|property)\b`, `(?m)^\s*#import\b`},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Pascal": {
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"{", "}"}, {"(*", "*)"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".pas", ".pp", ".inc"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{`(?im)^\s*((procedure|function|unit|uses|program|begin)\b|end[;.])`},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Perl": {
+		LineComments:              []string{"#"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{"#"},
+		MultiLineComments:         [][]string{{"=pod", "=cut"}, {"=head", "=cut"}, {"=begin", "=cut"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".pl", ".pm"},
+		FileNames:                 []string{},
+		Interpreters:              []string{"perl"},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Oracle PL/SQL": {
-		LineComments:      []string{"--"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".pkb"},
-		FileNames:         []string{},
+		LineComments:              []string{"--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}, {"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".pkb"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"PL/I": {
-		LineComments:      []string{"--"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".pl1"},
-		FileNames:         []string{},
+		LineComments:              []string{"--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}, {"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".pl1"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Python": {
-		LineComments:      []string{"#"},
-		MultiLineComments: [][]string{{"\"\"\"", "\"\"\""}},
-		Extensions:        []string{".py", ".python", ".ipynb"},
-		FileNames:         []string{},
+		LineComments:              []string{"#"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{"#"},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"\"\"\"", "\"\"\""}, {"'''", "'''"}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{"r", "u", "b", "f", "br", "rb", "fr", "rf"},
+		DocStringDelimiters:       []string{"\"\"\"", "'''"},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".py", ".python"},
+		FileNames:                 []string{},
+		Interpreters:              []string{"python"},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 
 	"RPG": {
-		LineComments:      []string{"#"},
-		MultiLineComments: [][]string{},
-		Extensions:        []string{".rpg"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".rpg"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{{Column: 7, Indicators: []string{"*"}}},
+		IgnoredColumns:            [][]int{{1, 5}, {81, 100}},
 	},
 	"Ruby": {
-		LineComments:      []string{"#"},
-		MultiLineComments: [][]string{{"=begin", "=end"}},
-		Extensions:        []string{".rb"},
-		FileNames:         []string{},
+		LineComments:              []string{"#"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{"#"},
+		MultiLineComments:         [][]string{{"=begin", "=end"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".rb"},
+		FileNames:                 []string{},
+		Interpreters:              []string{"ruby"},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Rust": {
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{"/*"},
+		StringDelimiters:          [][]string{{"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"\"", "\""}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{"'"},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".rs"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Scala": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".scala"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{"/*"},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"\"\"\"", "\"\"\""}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".scala"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Scss": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".scss"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".scss"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Shell": {
+		LineComments:              []string{"#"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{"#"},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".sh", ".bash", ".zsh", ".ksh"},
+		FileNames:                 []string{},
+		Interpreters:              []string{"sh", "bash", "zsh", "ksh", "dash", "ash"},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"SQL": {
-		LineComments:      []string{"--"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".sql"},
-		FileNames:         []string{},
+		LineComments:              []string{"--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}, {"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".sql"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Swift": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".swift"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{"/*"},
+		StringDelimiters:          [][]string{{"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{{"\"\"\"", "\"\"\""}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".swift"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
+	},
+	"Svelte": {
+		LineComments:              []string{},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"<!--", "-->"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".svelte"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages: map[string]string{
+			"script":            "JavaScript",
+			"script:js":         "JavaScript",
+			"script:javascript": "JavaScript",
+			"script:jsx":        "JavaScript",
+			"script:ts":         "TypeScript",
+			"script:typescript": "TypeScript",
+			"script:tsx":        "TypeScript",
+			"style":             "CSS",
+			"style:css":         "CSS",
+			"style:scss":        "Scss",
+			"style:less":        "Less",
+		},
+		ColumnComments: []ColumnComment{},
+		IgnoredColumns: [][]int{},
 	},
 	"TypeScript": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".ts", ".tsx"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}, {"'", "'"}},
+		MultiLineStringDelimiters: [][]string{{"`", "`"}},
+		EscapeCharacters:          []string{"\\"},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{"/"},
+		Extensions:                []string{".ts", ".tsx"},
+		FileNames:                 []string{},
+		Interpreters:              []string{"ts-node"},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"T-SQL": {
-		LineComments:      []string{"--"},
-		MultiLineComments: [][]string{},
-		Extensions:        []string{".tsql"},
-		FileNames:         []string{},
+		LineComments:              []string{"--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"'", "'"}, {"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".tsql"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Vue": {
-		LineComments:      []string{"<!--"},
-		MultiLineComments: [][]string{{"<!--", "-->"}},
-		Extensions:        []string{".vue"},
-		FileNames:         []string{},
+		LineComments:              []string{"<!--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"<!--", "-->"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".vue"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages: map[string]string{
+			"template":          "HTML",
+			"script":            "JavaScript",
+			"script:js":         "JavaScript",
+			"script:javascript": "JavaScript",
+			"script:jsx":        "JavaScript",
+			"script:ts":         "TypeScript",
+			"script:typescript": "TypeScript",
+			"script:tsx":        "TypeScript",
+			"style":             "CSS",
+			"style:css":         "CSS",
+			"style:scss":        "Scss",
+			"style:less":        "Less",
+		},
+		ColumnComments: []ColumnComment{},
+		IgnoredColumns: [][]int{},
 	},
 	"Visual Basic .NET": {
-		LineComments:      []string{"'"},
-		MultiLineComments: [][]string{},
-		Extensions:        []string{".vb"},
-		FileNames:         []string{},
+		LineComments:              []string{"'"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{{"\"", "\""}},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".vb"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"XML": {
-		LineComments:      []string{"<!--"},
-		MultiLineComments: [][]string{{"<!--", "-->"}},
-		Extensions:        []string{".xml", ".XML", ".xsd", ".xsl"},
-		FileNames:         []string{},
+		LineComments:              []string{"<!--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"<!--", "-->"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".xml", ".XML", ".xsd", ".xsl"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"XHTML": {
-		LineComments:      []string{"<!--"},
-		MultiLineComments: [][]string{{"<!--", "-->"}},
-		Extensions:        []string{".xhtml"},
-		FileNames:         []string{},
+		LineComments:              []string{"<!--"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"<!--", "-->"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".xhtml"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"YAML": {
-		LineComments:      []string{"#"},
-		MultiLineComments: [][]string{},
-		Extensions:        []string{".yaml", ".yml"},
-		FileNames:         []string{},
+		LineComments:              []string{"#"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".yaml", ".yml"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Terraform": {
-		LineComments:      []string{},
-		MultiLineComments: [][]string{},
-		Extensions:        []string{".tf"},
-		FileNames:         []string{},
+		LineComments:              []string{},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".tf"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"JCL": {
-		LineComments:      []string{"//"},
-		MultiLineComments: [][]string{{"/*", "*/"}},
-		Extensions:        []string{".jcl", ".JCL"},
-		FileNames:         []string{},
+		LineComments:              []string{"//"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{{"/*", "*/"}},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".jcl", ".JCL"},
+		FileNames:                 []string{},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 	"Docker": {
-		LineComments:      []string{"#"},
-		MultiLineComments: [][]string{},
-		Extensions:        []string{".dockerfile"},
-		FileNames:         []string{"Dockerfile"},
+		LineComments:              []string{"#"},
+		LineCommentExceptions:     []string{},
+		WordStartLineComments:     []string{},
+		MultiLineComments:         [][]string{},
+		NestedComments:            []string{},
+		StringDelimiters:          [][]string{},
+		MultiLineStringDelimiters: [][]string{},
+		EscapeCharacters:          []string{},
+		StringPrefixes:            []string{},
+		DocStringDelimiters:       []string{},
+		LabelDelimiters:           []string{},
+		RegexDelimiters:           []string{},
+		Extensions:                []string{".dockerfile"},
+		FileNames:                 []string{"Dockerfile"},
+		Interpreters:              []string{},
+		Priority:                  0,
+		Heuristics:                []string{},
+		EmbeddedLanguages:         map[string]string{},
+		ColumnComments:            []ColumnComment{},
+		IgnoredColumns:            [][]int{},
 	},
 }
 
 // Function to look up file information based on its extension
 /*
 @ext should match exactly as above, ".java" etc.
+When several languages declare the extension, the one with the highest Priority is returned, ties are broken by name.
 */
 func LookupByExtension(ext string) (string, LanguageInfo, bool) {
-	for lang, info := range Languages {
-		for _, languageExt := range info.Extensions {
+	candidates := lookupCandidatesByExtension(ext)
+	if len(candidates) == 0 {
+		return "", LanguageInfo{}, false
+	}
+	return candidates[0], Languages[candidates[0]], true
+}
+
+// LookupByExtensionAndContent looks up the language of a file by its extension. When several languages declare the
+// extension, their Heuristics are tried against the start of the file in priority order and the first match wins.
+// If none match, the highest priority language wins. Returns the rule that decided the language.
+func LookupByExtensionAndContent(ext string, filePath string) (string, LanguageInfo, string, bool) {
+	candidates := lookupCandidatesByExtension(ext)
+	if len(candidates) == 0 {
+		return "", LanguageInfo{}, "", false
+	}
+	if len(candidates) == 1 {
+		return candidates[0], Languages[candidates[0]], "extension " + ext, true
+	}
+
+	sample, err := readFileSample(filePath, HeuristicsSampleBytes)
+	if err != nil {
+		logger.Debug("Unable to read ", filePath, " for heuristics: ", err)
+	}
+	for _, lang := range candidates {
+		for _, heuristic := range Languages[lang].Heuristics {
+			regex := compileHeuristic(heuristic)
+			if regex != nil && regex.Match(sample) {
+				return lang, Languages[lang], "heuristic " + heuristic + " for " + ext, true
+			}
+		}
+	}
+	return candidates[0], Languages[candidates[0]], "priority for " + ext + " over " + strings.Join(candidates[1:], ", "), true
+}
+
+// returns every language declaring the extension, highest Priority first and then by name
+func lookupCandidatesByExtension(ext string) []string {
+	candidates := []string{}
+	for _, lang := range languageNames {
+		for _, languageExt := range Languages[lang].Extensions {
 			if languageExt == ext {
-				return lang, info, true
+				candidates = append(candidates, lang)
+				break
 			}
 		}
 	}
-	return "", LanguageInfo{}, false
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return Languages[candidates[a]].Priority > Languages[candidates[b]].Priority
+	})
+	return candidates
+}
+
+// compiles a heuristic once, invalid regular expressions are logged and never match
+func compileHeuristic(heuristic string) *regexp.Regexp {
+	if cached, found := heuristicsCache.Load(heuristic); found {
+		return cached.(*regexp.Regexp)
+	}
+	regex, err := regexp.Compile(heuristic)
+	if err != nil {
+		logger.Error("Invalid heuristic '", heuristic, "' in languages config: ", err)
+	}
+	heuristicsCache.Store(heuristic, regex)
+	return regex
+}
+
+// reads up to maxBytes from the start of a file
+func readFileSample(filePath string, maxBytes int) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer f.Close()
+
+	sample := make([]byte, maxBytes)
+	length, err := io.ReadFull(f, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return []byte{}, err
+	}
+	return sample[:length], nil
 }
 
 func LookupByFileName(fileName string) (string, LanguageInfo, bool) {
-	for lang, info := range Languages {
+	for _, lang := range languageNames {
+		info := Languages[lang]
 		for _, languageFileName := range info.FileNames {
 			if languageFileName == fileName {
 				return lang, info, true
@@ -268,6 +1281,58 @@ func LookupByFileName(fileName string) (string, LanguageInfo, bool) {
 	return "", LanguageInfo{}, false
 }
 
+// LookupByInterpreter finds the language of a shebang interpreter, ex: "python3" or "bash"
+func LookupByInterpreter(interpreter string) (string, LanguageInfo, bool) {
+	interpreter = strings.ToLower(interpreter)
+	// python3.11 should match python
+	withoutVersion := strings.TrimRight(interpreter, "0123456789.")
+	for _, lang := range languageNames {
+		info := Languages[lang]
+		for _, languageInterpreter := range info.Interpreters {
+			languageInterpreter = strings.ToLower(languageInterpreter)
+			if languageInterpreter == interpreter || languageInterpreter == withoutVersion {
+				return lang, info, true
+			}
+		}
+	}
+	return "", LanguageInfo{}, false
+}
+
+// LookupByModeline finds the language named by a vim file type or emacs mode, ex: "ruby", "c++" or "sh"
+// the name is matched against language names, then interpreters, then extensions
+func LookupByModeline(name string) (string, LanguageInfo, bool) {
+	for _, lang := range languageNames {
+		if strings.EqualFold(lang, name) {
+			return lang, Languages[lang], true
+		}
+	}
+	if lang, info, found := LookupByInterpreter(name); found {
+		return lang, info, true
+	}
+	for _, lang := range languageNames {
+		info := Languages[lang]
+		for _, languageExt := range info.Extensions {
+			if strings.EqualFold(languageExt, "."+name) {
+				return lang, info, true
+			}
+		}
+	}
+	return "", LanguageInfo{}, false
+}
+
+// names of all languages in alphabetical order, so lookups do not depend on map iteration order
+// Lookups run for every file, so the names are only sorted again when LoadLanguages changes the languages
+var languageNames = sortLanguageNames(Languages)
+
+func sortLanguageNames(languages map[string]LanguageInfo) []string {
+	names := make([]string, 0, len(languages))
+	for lang := range languages {
+		names = append(names, lang)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func PrintLanguages() {
 	logger.Info("Supported Languages:")
 	// Create a buffer to hold the JSON data
@@ -304,4 +1369,5 @@ func LoadLanguages(fileName string) {
 	if err != nil {
 		logger.LogStackTraceAndExit(err)
 	}
+	languageNames = sortLanguageNames(Languages)
 }
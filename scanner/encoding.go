@@ -0,0 +1,219 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Encoding string
+
+const (
+	EncodingAuto        Encoding = "auto"
+	EncodingUTF8        Encoding = "UTF-8"
+	EncodingUTF8BOM     Encoding = "UTF-8 BOM"
+	EncodingUTF16LE     Encoding = "UTF-16LE"
+	EncodingUTF16BE     Encoding = "UTF-16BE"
+	EncodingWindows1252 Encoding = "Windows-1252"
+	EncodingLatin1      Encoding = "ISO-8859-1"
+)
+
+// encoding of all scanned files, set by the --encoding CLI argument. Detected per file when auto
+var FileEncoding = EncodingAuto
+
+// share of the UTF-16 code units in a sample without a byte order mark that must be ASCII text to detect UTF-16
+const UTF16ASCIIRatio = 0.7
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+var utf16LEBOM = []byte{0xff, 0xfe}
+var utf16BEBOM = []byte{0xfe, 0xff}
+
+// ParseEncoding converts the value of the --encoding CLI argument into an Encoding
+func ParseEncoding(value string) (Encoding, bool) {
+	switch strings.ToLower(value) {
+	case "auto", "":
+		return EncodingAuto, true
+	case "utf-8", "utf8":
+		return EncodingUTF8, true
+	case "utf-16le", "utf16le":
+		return EncodingUTF16LE, true
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, true
+	case "windows-1252", "cp1252":
+		return EncodingWindows1252, true
+	case "iso-8859-1", "latin-1", "latin1":
+		return EncodingLatin1, true
+	}
+	return "", false
+}
+
+// DetectEncoding detects the encoding of a file from a sample of its first bytes
+// Checks for a byte order mark, then for UTF-16 without one, then for valid UTF-8. Anything else is treated as Windows-1252
+// Returns the encoding and the length of the byte order mark to skip
+func DetectEncoding(sample []byte) (Encoding, int) {
+	if bytes.HasPrefix(sample, utf8BOM) {
+		return EncodingUTF8BOM, len(utf8BOM)
+	}
+	if bytes.HasPrefix(sample, utf16LEBOM) {
+		return EncodingUTF16LE, len(utf16LEBOM)
+	}
+	if bytes.HasPrefix(sample, utf16BEBOM) {
+		return EncodingUTF16BE, len(utf16BEBOM)
+	}
+	if isUTF16Text(sample, false) {
+		return EncodingUTF16LE, 0
+	}
+	if isUTF16Text(sample, true) {
+		return EncodingUTF16BE, 0
+	}
+	// a rune cut off by the end of the sample does not make it invalid
+	for i := 1; i < utf8.UTFMax && i <= len(sample); i++ {
+		if utf8.RuneStart(sample[len(sample)-i]) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				sample = sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(sample) {
+		return EncodingUTF8, 0
+	}
+	return EncodingWindows1252, 0
+}
+
+// checks if a sample without a byte order mark is UTF-16 by looking for ASCII text, ex: "a\x00b\x00" in little endian
+func isUTF16Text(sample []byte, bigEndian bool) bool {
+	codeUnitCount := len(sample) / 2
+	if codeUnitCount == 0 {
+		return false
+	}
+	asciiCount := 0
+	for i := 0; i+1 < len(sample); i += 2 {
+		low, high := sample[i], sample[i+1]
+		if bigEndian {
+			low, high = high, low
+		}
+		// U+0000 does not occur in text
+		if low == 0 && high == 0 {
+			return false
+		}
+		if high == 0 && (low >= 0x20 && low < 0x7f || low == '\t' || low == '\n' || low == '\r') {
+			asciiCount++
+		}
+	}
+	return float64(asciiCount)/float64(codeUnitCount) >= UTF16ASCIIRatio
+}
+
+// byte order mark of an encoding, nil if the encoding has none
+func byteOrderMark(encoding Encoding) []byte {
+	switch encoding {
+	case EncodingUTF8, EncodingUTF8BOM:
+		return utf8BOM
+	case EncodingUTF16LE:
+		return utf16LEBOM
+	case EncodingUTF16BE:
+		return utf16BEBOM
+	}
+	return nil
+}
+
+// characters of Windows-1252 which differ from ISO-8859-1, undefined bytes keep their ISO-8859-1 meaning
+var windows1252Runes = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+// NewDecodingReader converts the content of a reader from the encoding into UTF-8
+// UTF-8 content is returned as is, the byte order mark must already be skipped
+func NewDecodingReader(source *bufio.Reader, encoding Encoding) io.Reader {
+	switch encoding {
+	case EncodingUTF16LE:
+		return &decodingReader{source: source, decodeRune: func(source *bufio.Reader) (rune, error) { return decodeUTF16Rune(source, false) }}
+	case EncodingUTF16BE:
+		return &decodingReader{source: source, decodeRune: func(source *bufio.Reader) (rune, error) { return decodeUTF16Rune(source, true) }}
+	case EncodingWindows1252:
+		return &decodingReader{source: source, decodeRune: decodeWindows1252Rune}
+	case EncodingLatin1:
+		return &decodingReader{source: source, decodeRune: decodeLatin1Rune}
+	}
+	return source
+}
+
+// decodingReader converts one rune at a time into UTF-8
+type decodingReader struct {
+	source     *bufio.Reader
+	decodeRune func(source *bufio.Reader) (rune, error)
+	pending    []byte // decoded bytes which did not fit into the previous Read
+}
+
+func (reader *decodingReader) Read(p []byte) (int, error) {
+	for len(reader.pending) < len(p) {
+		r, err := reader.decodeRune(reader.source)
+		if err != nil {
+			if len(reader.pending) > 0 {
+				break
+			}
+			return 0, err
+		}
+		reader.pending = utf8.AppendRune(reader.pending, r)
+	}
+	n := copy(p, reader.pending)
+	reader.pending = reader.pending[n:]
+	return n, nil
+}
+
+// decodes the UTF-16 code unit at the start of the bytes
+func utf16CodeUnit(codeUnit []byte, bigEndian bool) rune {
+	if bigEndian {
+		return rune(codeUnit[0])<<8 | rune(codeUnit[1])
+	}
+	return rune(codeUnit[1])<<8 | rune(codeUnit[0])
+}
+
+// decodes a single character, a trailing odd byte is ignored
+func decodeUTF16Rune(source *bufio.Reader, bigEndian bool) (rune, error) {
+	codeUnit := [2]byte{}
+	if _, err := io.ReadFull(source, codeUnit[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	r := utf16CodeUnit(codeUnit[:], bigEndian)
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+	// characters outside of the basic multilingual plane are split into a surrogate pair
+	// the next code unit is only consumed if it completes the pair, so a broken pair does not swallow a line break
+	next, err := source.Peek(2)
+	if err != nil {
+		return utf8.RuneError, nil
+	}
+	decoded := utf16.DecodeRune(r, utf16CodeUnit(next, bigEndian))
+	if decoded != utf8.RuneError {
+		source.Discard(2)
+	}
+	return decoded, nil
+}
+
+func decodeWindows1252Rune(source *bufio.Reader) (rune, error) {
+	b, err := source.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if r, ok := windows1252Runes[b]; ok {
+		return r, nil
+	}
+	return rune(b), nil
+}
+
+func decodeLatin1Rune(source *bufio.Reader) (rune, error) {
+	b, err := source.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return rune(b), nil
+}
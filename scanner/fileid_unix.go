@@ -0,0 +1,21 @@
+//go:build unix
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+)
+
+// returns the device and inode of the file or directory at the path, symbolic links are followed
+func getFileID(path string) (fileID, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{device: uint64(stat.Dev), inode: uint64(stat.Ino)}, true
+}
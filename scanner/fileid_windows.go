@@ -0,0 +1,26 @@
+//go:build windows
+
+package scanner
+
+import (
+	"syscall"
+)
+
+// returns the volume serial number and file index of the file or directory at the path, symbolic links are followed
+func getFileID(path string) (fileID, bool) {
+	pathPointer, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	// FILE_FLAG_BACKUP_SEMANTICS is needed to open a directory
+	handle, err := syscall.CreateFile(pathPointer, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer syscall.CloseHandle(handle)
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &info); err != nil {
+		return fileID{}, false
+	}
+	return fileID{device: uint64(info.VolumeSerialNumber), inode: uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow)}, true
+}
@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FileFilter narrows a scan down to some languages or file extensions, ex: only the backend languages
+// An empty include list includes everything, excludes take precedence over includes
+type FileFilter struct {
+	IncludeLanguages  []string // language names as configured, ex: "Golang"
+	ExcludeLanguages  []string
+	IncludeExtensions []string // lower case extensions with a leading dot, ex: ".go" or ".min.js"
+	ExcludeExtensions []string
+}
+
+// Filter is applied while walking directories and scanning files, set by the --include-lang, --exclude-lang, --include-ext and --exclude-ext CLI arguments
+var Filter = FileFilter{}
+
+// NewFileFilter creates a filter from the language names and extensions given by the user
+// Language names are matched case insensitively against the configured languages, extensions may omit the leading dot, ex: "js"
+// Returns an error for a language that is not configured
+func NewFileFilter(includeLanguages []string, excludeLanguages []string, includeExtensions []string, excludeExtensions []string) (FileFilter, error) {
+	filter := FileFilter{
+		IncludeExtensions: normalizeExtensions(includeExtensions),
+		ExcludeExtensions: normalizeExtensions(excludeExtensions),
+	}
+	var err error
+	if filter.IncludeLanguages, err = normalizeLanguageNames(includeLanguages); err != nil {
+		return FileFilter{}, err
+	}
+	if filter.ExcludeLanguages, err = normalizeLanguageNames(excludeLanguages); err != nil {
+		return FileFilter{}, err
+	}
+	return filter, nil
+}
+
+func normalizeExtensions(extensions []string) []string {
+	normalized := []string{}
+	for _, extension := range extensions {
+		extension = strings.ToLower(strings.TrimSpace(extension))
+		if extension == "" {
+			continue
+		}
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		normalized = append(normalized, extension)
+	}
+	return normalized
+}
+
+func normalizeLanguageNames(userLanguageNames []string) ([]string, error) {
+	normalized := []string{}
+	for _, languageName := range userLanguageNames {
+		languageName = strings.TrimSpace(languageName)
+		if languageName == "" {
+			continue
+		}
+		found := false
+		for _, lang := range languageNames {
+			if strings.EqualFold(lang, languageName) {
+				normalized = append(normalized, lang)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown language '%s', use --print-languages to list the supported languages", languageName)
+		}
+	}
+	return normalized, nil
+}
+
+// ExtensionSkipReason returns why a file with the name is left out of the scan, or an empty string if it is not
+// Extensions are matched against the end of the name, so an extension may hold several dots, ex: ".min.js" matches app.min.js
+func (filter FileFilter) ExtensionSkipReason(fileName string) string {
+	fileName = strings.ToLower(fileName)
+	for _, extension := range filter.ExcludeExtensions {
+		if strings.HasSuffix(fileName, extension) {
+			return "excluded extension: " + extension
+		}
+	}
+	if len(filter.IncludeExtensions) == 0 {
+		return ""
+	}
+	for _, extension := range filter.IncludeExtensions {
+		if strings.HasSuffix(fileName, extension) {
+			return ""
+		}
+	}
+	// the suffix is empty for files without one, ex: Dockerfile
+	suffix := ParseFileSuffix(fileName)
+	if suffix == "" {
+		return "extension not included: none"
+	}
+	return "extension not included: " + suffix
+}
+
+// LanguageSkipReason returns why a file of the language is left out of the scan, or an empty string if it is not
+func (filter FileFilter) LanguageSkipReason(languageName string) string {
+	if slices.Contains(filter.ExcludeLanguages, languageName) {
+		return "excluded language: " + languageName
+	}
+	if len(filter.IncludeLanguages) > 0 && !slices.Contains(filter.IncludeLanguages, languageName) {
+		return "language not included: " + languageName
+	}
+	return ""
+}
+
+// returns why a file is left out when it may be any of the languages, ex: a .h file which may be C or C++
+// A file is only left out when all of the languages are, otherwise its content decides when it is scanned
+func (filter FileFilter) candidatesSkipReason(languageNames []string) string {
+	allExcluded := true
+	for _, languageName := range languageNames {
+		if filter.LanguageSkipReason(languageName) == "" {
+			return ""
+		}
+		allExcluded = allExcluded && slices.Contains(filter.ExcludeLanguages, languageName)
+	}
+	if len(languageNames) == 1 {
+		return filter.LanguageSkipReason(languageNames[0])
+	}
+	if allExcluded {
+		return "excluded language: " + strings.Join(languageNames, " or ")
+	}
+	return "language not included: " + strings.Join(languageNames, " or ")
+}
@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scans the directories with the filter and returns the results by file name
+func scanWithFilter(t *testing.T, filter FileFilter, targetPaths ...string) map[string]FileScanResults {
+	t.Helper()
+	Filter = filter
+	defer func() { Filter = FileFilter{} }()
+	results := map[string]FileScanResults{}
+	for result := range ScanDirectoriesToChannel(targetPaths, []IgnoreFile{}, 4) {
+		results[filepath.Base(result.FilePath)] = result
+	}
+	return results
+}
+
+func Test_filter_NewFileFilter(t *testing.T) {
+	filter, err := NewFileFilter([]string{"javascript", " Python "}, []string{}, []string{"go", ".JS", ""}, []string{"min.js"})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"JavaScript", "Python"}, filter.IncludeLanguages)
+	assert.Equal(t, []string{}, filter.ExcludeLanguages)
+	assert.Equal(t, []string{".go", ".js"}, filter.IncludeExtensions)
+	assert.Equal(t, []string{".min.js"}, filter.ExcludeExtensions)
+
+	_, err = NewFileFilter([]string{}, []string{"Pyhton"}, []string{}, []string{})
+	assert.ErrorContains(t, err, "unknown language 'Pyhton'")
+}
+
+func Test_filter_NewFileFilter_documented_examples(t *testing.T) {
+	// the examples of the --include-lang, --exclude-lang, --include-ext and --exclude-ext help texts
+	filter, err := NewFileFilter([]string{"Golang", "Java"}, []string{"YAML", "XML"}, []string{"go", "java"}, []string{"min.js", "json"})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Golang", "Java"}, filter.IncludeLanguages)
+	assert.Equal(t, []string{"YAML", "XML"}, filter.ExcludeLanguages)
+	assert.Equal(t, []string{".go", ".java"}, filter.IncludeExtensions)
+	assert.Equal(t, []string{".min.js", ".json"}, filter.ExcludeExtensions)
+
+	// the README example
+	_, err = NewFileFilter([]string{"Golang", "Java", "Python"}, []string{}, []string{}, []string{})
+	assert.Nil(t, err)
+}
+
+func Test_filter_skip_reasons(t *testing.T) {
+	filter := FileFilter{
+		IncludeLanguages:  []string{"C", "JavaScript"},
+		ExcludeLanguages:  []string{"JavaScript"},
+		IncludeExtensions: []string{".c", ".h", ".js"},
+		ExcludeExtensions: []string{".h"},
+	}
+
+	// Assert
+	assert.Equal(t, "", filter.ExtensionSkipReason("main.c"))
+	assert.Equal(t, "excluded extension: .h", filter.ExtensionSkipReason("main.H"))
+	assert.Equal(t, "extension not included: .py", filter.ExtensionSkipReason("main.py"))
+	assert.Equal(t, "extension not included: none", filter.ExtensionSkipReason("Dockerfile"))
+	assert.Equal(t, "", FileFilter{}.ExtensionSkipReason("Dockerfile"))
+	assert.Equal(t, "", filter.LanguageSkipReason("C"))
+	// excludes take precedence over includes
+	assert.Equal(t, "excluded language: JavaScript", filter.LanguageSkipReason("JavaScript"))
+	assert.Equal(t, "language not included: Python", filter.LanguageSkipReason("Python"))
+	// a file that may be one of several languages is kept when any of them is
+	assert.Equal(t, "", filter.candidatesSkipReason([]string{"C Header", "C"}))
+	assert.Equal(t, "language not included: C Header or C++ Header", filter.candidatesSkipReason([]string{"C Header", "C++ Header"}))
+	assert.Equal(t, "excluded language: JavaScript", filter.candidatesSkipReason([]string{"JavaScript"}))
+}
+
+func Test_filter_extensions_with_several_dots(t *testing.T) {
+	filter, err := NewFileFilter([]string{}, []string{}, []string{}, []string{"min.js"})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "excluded extension: .min.js", filter.ExtensionSkipReason("app.min.js"))
+	assert.Equal(t, "excluded extension: .min.js", filter.ExtensionSkipReason("APP.MIN.JS"))
+	assert.Equal(t, "", filter.ExtensionSkipReason("app.js"))
+	assert.Equal(t, "", filter.ExtensionSkipReason("admin.js"))
+	// an extension does not match a file that only ends with the same letters
+	assert.Equal(t, "extension not included: .js", FileFilter{IncludeExtensions: []string{".s"}}.ExtensionSkipReason("app.js"))
+	assert.Equal(t, "", FileFilter{IncludeExtensions: []string{".d.ts"}}.ExtensionSkipReason("types.d.ts"))
+}
+
+func Test_filter_ScanDirectoriesToChannel_include_languages(t *testing.T) {
+	results := scanWithFilter(t, FileFilter{IncludeLanguages: []string{"JavaScript"}}, "test-files/js", "test-files/c", "test-files/docker")
+
+	// Assert
+	assert.Equal(t, 2+3+2, len(results))
+	assert.Equal(t, "", results["easy.js"].SkipReason)
+	assert.NotZero(t, results["easy.js"].CodeLineCount)
+	// left out files are reported as skipped without being scanned
+	assert.Equal(t, "language not included: C", results["hard.c"].SkipReason)
+	assert.Equal(t, "C", results["hard.c"].LanguageName)
+	assert.Zero(t, results["hard.c"].TotalLines)
+	assert.Equal(t, "language not included: Docker", results["Dockerfile"].SkipReason)
+}
+
+func Test_filter_ScanDirectoriesToChannel_extensions(t *testing.T) {
+	results := scanWithFilter(t, FileFilter{IncludeExtensions: []string{".c", ".js"}, ExcludeExtensions: []string{".js"}}, "test-files/js", "test-files/c", "test-files/docker")
+
+	// Assert
+	assert.Equal(t, "", results["hard.c"].SkipReason)
+	assert.Equal(t, "excluded extension: .js", results["easy.js"].SkipReason)
+	assert.Equal(t, "extension not included: .dockerfile", results["test.Dockerfile"].SkipReason)
+	assert.Equal(t, "extension not included: none", results["Dockerfile"].SkipReason)
+}
+
+func Test_filter_ScanDirectoriesToChannel_extensions_with_several_dots(t *testing.T) {
+	results := scanWithFilter(t, FileFilter{ExcludeExtensions: []string{".min.js"}}, "test-files/generated", "test-files/js")
+
+	// Assert
+	assert.Equal(t, "excluded extension: .min.js", results["bundle.min.js"].SkipReason)
+	assert.Equal(t, "", results["easy.js"].SkipReason)
+	assert.Equal(t, "", results["client.gen.ts"].SkipReason)
+}
+
+func Test_filter_ScanDirectoriesToChannel_languages_sharing_an_extension(t *testing.T) {
+	results := scanWithFilter(t, FileFilter{IncludeLanguages: []string{"C++ Header", "MATLAB"}}, "test-files/ambiguous")
+
+	// Assert
+	// .h and .m files are detected from their content before they are left out
+	assert.Equal(t, "", results["widget.h"].SkipReason)
+	assert.Equal(t, "C++ Header", results["widget.h"].LanguageName)
+	assert.Equal(t, "language not included: Objective-C", results["view.h"].SkipReason)
+	assert.Equal(t, "language not included: C Header", results["plain.h"].SkipReason)
+	assert.Equal(t, "", results["area.m"].SkipReason)
+	assert.Equal(t, "language not included: Objective-C", results["view.m"].SkipReason)
+	// none of the languages of .inc files are included, so they are left out without being opened
+	assert.Equal(t, "language not included: PHP or Pascal", results["config.inc"].SkipReason)
+	assert.Equal(t, "", results["config.inc"].LanguageName)
+}
+
+func Test_filter_WalkDirectory_leaves_out_filtered_files(t *testing.T) {
+	Filter = FileFilter{ExcludeLanguages: []string{"C"}}
+	defer func() { Filter = FileFilter{} }()
+
+	result := WalkDirectory("test-files/c", []IgnoreFile{})
+
+	// Assert
+	assert.Empty(t, result)
+}
@@ -0,0 +1,357 @@
+package scanner
+
+import (
+	"fmt"
+	"go-cloc/logger"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// UseVCSIgnoreFiles applies the ignore files found while walking a directory, turned off by the --no-vcs-ignore CLI argument
+var UseVCSIgnoreFiles = true
+
+// IgnoreFileNames are the ignore files applied to the directory they are found in and its subdirectories
+// Later names take precedence over earlier ones in the same directory
+var IgnoreFileNames = []string{".gitignore", ".ignore", ".go-cloc-ignore"}
+
+// IgnoreFile holds the patterns of an ignore file in the gitignore format
+// Patterns are matched against paths relative to BaseDir, the directory of the ignore file
+type IgnoreFile struct {
+	Path     string // path of the ignore file, empty for patterns that were not read from a file
+	BaseDir  string // absolute path of the directory the patterns are relative to
+	Patterns []IgnorePattern
+}
+
+// IgnorePattern is a single line of an ignore file, ex: "!build/keep/" or "**/node_modules"
+type IgnorePattern struct {
+	Pattern       string // the pattern as written
+	Negated       bool   // starts with !, a match re-includes a path that an earlier pattern ignored
+	DirectoryOnly bool   // ends with /, only matches directories
+	Line          int    // line number in the ignore file, starting at 1
+	regex         *regexp.Regexp
+}
+
+// NewIgnoreFile compiles the lines of an ignore file in the gitignore format, the patterns are relative to the base directory
+// Blank lines and comments starting with # are skipped
+func NewIgnoreFile(baseDir string, lines []string) IgnoreFile {
+	absBaseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+	ignoreFile := IgnoreFile{BaseDir: absBaseDir, Patterns: []IgnorePattern{}}
+	for index, line := range lines {
+		if ignorePattern, ok := compileIgnorePattern(line); ok {
+			ignorePattern.Line = index + 1
+			logger.Debug("Adding pattern ", ignorePattern.Pattern, " as ", ignorePattern.regex)
+			ignoreFile.Patterns = append(ignoreFile.Patterns, ignorePattern)
+		}
+	}
+	return ignoreFile
+}
+
+// LoadIgnoreFile reads an ignore file, its patterns are relative to the directory of the file
+// Exits the program if the file cannot be read
+func LoadIgnoreFile(path string) IgnoreFile {
+	logger.Debug("Reading ignore file ", path)
+	lines, err := readIgnoreFileLines(path)
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+	ignoreFile := NewIgnoreFile(filepath.Dir(path), lines)
+	ignoreFile.Path = path
+	return ignoreFile
+}
+
+// Describe returns where the pattern was defined for logging, ex: "'*.js' from .gitignore:3"
+func (ignoreFile IgnoreFile) Describe(pattern *IgnorePattern) string {
+	if ignoreFile.Path == "" {
+		return fmt.Sprintf("'%s' from pattern %d relative to %s", pattern.Pattern, pattern.Line, ignoreFile.BaseDir)
+	}
+	return fmt.Sprintf("'%s' from %s:%d", pattern.Pattern, ignoreFile.Path, pattern.Line)
+}
+
+// Match returns the last pattern that matches the path, or nil if none does
+// The path must be absolute, paths outside of BaseDir and BaseDir itself never match
+func (ignoreFile IgnoreFile) Match(absPath string, isDir bool) *IgnorePattern {
+	relPath, err := filepath.Rel(ignoreFile.BaseDir, absPath)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	relPath = filepath.ToSlash(relPath)
+	for i := len(ignoreFile.Patterns) - 1; i >= 0; i-- {
+		pattern := &ignoreFile.Patterns[i]
+		if pattern.DirectoryOnly && !isDir {
+			continue
+		}
+		if pattern.regex.MatchString(relPath) {
+			return pattern
+		}
+	}
+	return nil
+}
+
+// IsIgnored checks if a path is ignored by the ignore files, later files take precedence over earlier ones
+// Within a file the last matching pattern decides, so a negated pattern can re-include a path
+func IsIgnored(ignoreFiles []IgnoreFile, absPath string, isDir bool) bool {
+	_, pattern := matchIgnoreFiles(ignoreFiles, absPath, isDir)
+	return pattern != nil && !pattern.Negated
+}
+
+// returns the pattern that decides if the path is ignored and the file it belongs to, or nil if no pattern matches
+func matchIgnoreFiles(ignoreFiles []IgnoreFile, absPath string, isDir bool) (*IgnoreFile, *IgnorePattern) {
+	for i := len(ignoreFiles) - 1; i >= 0; i-- {
+		if pattern := ignoreFiles[i].Match(absPath, isDir); pattern != nil {
+			return &ignoreFiles[i], pattern
+		}
+	}
+	return nil, nil
+}
+
+// ignoreScope holds the ignore files that apply while a directory is walked depth first
+// Ignore files found in a directory only apply inside it, so they are dropped once the walk leaves the directory
+type ignoreScope struct {
+	ignoreFiles []IgnoreFile // given by the user, they take precedence over the discovered ones
+	discovered  []IgnoreFile // found in the current directory and its parents, the deepest last
+}
+
+// creates the scope for walking the target directory
+// When the target is inside a git repository, the ignore files of the parent directories up to the root of the repository apply too
+// Ignore files given by the user that are outside of the target directory have their patterns anchored to the target directory instead
+func newIgnoreScope(absTargetPath string, ignoreFiles []IgnoreFile) *ignoreScope {
+	rootDir := absTargetPath
+	if !isDirectory(rootDir) {
+		rootDir = filepath.Dir(rootDir)
+	}
+	scope := &ignoreScope{ignoreFiles: make([]IgnoreFile, len(ignoreFiles))}
+	for i, ignoreFile := range ignoreFiles {
+		if ignoreFile.BaseDir != rootDir && !isInsideDirectory(ignoreFile.BaseDir, rootDir) {
+			logger.Debug("Ignore file ", ignoreFile.Path, " is outside of ", rootDir, ", its patterns are relative to ", rootDir)
+			ignoreFile.BaseDir = rootDir
+		}
+		scope.ignoreFiles[i] = ignoreFile
+	}
+	if !UseVCSIgnoreFiles {
+		return scope
+	}
+	var parents []string
+	for dir := filepath.Dir(absTargetPath); ; dir = filepath.Dir(dir) {
+		parents = append(parents, dir)
+		if isDirectory(filepath.Join(dir, ".git")) {
+			for i := len(parents) - 1; i >= 0; i-- {
+				scope.addDirectory(parents[i])
+			}
+			break
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	return scope
+}
+
+// drops the ignore files of directories that do not contain the path, the walk has left them
+func (scope *ignoreScope) enter(absPath string) {
+	for len(scope.discovered) > 0 {
+		if isInsideDirectory(scope.discovered[len(scope.discovered)-1].BaseDir, absPath) {
+			return
+		}
+		scope.discovered = scope.discovered[:len(scope.discovered)-1]
+	}
+}
+
+// loads the ignore files in the directory, the exclude file of a git repository has the lowest precedence
+func (scope *ignoreScope) addDirectory(absDir string) {
+	if !UseVCSIgnoreFiles {
+		return
+	}
+	if isDirectory(filepath.Join(absDir, ".git")) {
+		scope.addIgnoreFile(absDir, filepath.Join(absDir, ".git", "info", "exclude"))
+	}
+	for _, name := range IgnoreFileNames {
+		scope.addIgnoreFile(absDir, filepath.Join(absDir, name))
+	}
+}
+
+func (scope *ignoreScope) addIgnoreFile(absDir string, path string) {
+	lines, err := readIgnoreFileLines(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Unable to read ignore file ", path, ": ", err)
+		}
+		return
+	}
+	logger.Debug("Applying ignore file ", path)
+	ignoreFile := NewIgnoreFile(absDir, lines)
+	ignoreFile.Path = path
+	scope.discovered = append(scope.discovered, ignoreFile)
+}
+
+// returns the pattern that decides if the path is ignored and the file it belongs to, or nil if no pattern matches
+func (scope *ignoreScope) match(absPath string, isDir bool) (*IgnoreFile, *IgnorePattern) {
+	if ignoreFile, pattern := matchIgnoreFiles(scope.ignoreFiles, absPath, isDir); pattern != nil {
+		return ignoreFile, pattern
+	}
+	return matchIgnoreFiles(scope.discovered, absPath, isDir)
+}
+
+// checks if the path is inside the directory, both must be clean absolute paths
+func isInsideDirectory(dir string, path string) bool {
+	if !strings.HasPrefix(path, dir) || len(path) == len(dir) {
+		return false
+	}
+	return os.IsPathSeparator(dir[len(dir)-1]) || os.IsPathSeparator(path[len(dir)])
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// compiles a line of an ignore file into a regular expression over slash separated relative paths
+// Returns false for blank lines and comments
+func compileIgnorePattern(line string) (IgnorePattern, bool) {
+	pattern := trimUnescapedTrailingSpaces(line)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return IgnorePattern{}, false
+	}
+	ignorePattern := IgnorePattern{Pattern: pattern}
+	if strings.HasPrefix(pattern, "!") {
+		ignorePattern.Negated = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		ignorePattern.DirectoryOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	// a slash at the start or in the middle anchors the pattern to the base directory, otherwise it matches a name at any depth
+	isAnchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return IgnorePattern{}, false
+	}
+
+	regex := strings.Builder{}
+	regex.WriteString("^")
+	if !isAnchored {
+		regex.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); {
+		isSegmentStart := i == 0 || pattern[i-1] == '/'
+		switch {
+		case isSegmentStart && pattern[i:] == "**":
+			// a trailing /** matches everything inside the directory
+			regex.WriteString(".*")
+			i += 2
+		case isSegmentStart && strings.HasPrefix(pattern[i:], "**/"):
+			// a leading **/ or a /**/ matches zero or more directories
+			regex.WriteString("(?:.*/)?")
+			i += 3
+		case pattern[i] == '*':
+			// any other asterisks do not match a /
+			regex.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			regex.WriteString("[^/]")
+			i++
+		case pattern[i] == '[':
+			class, length, ok := globCharacterClass(pattern[i:])
+			if ok {
+				regex.WriteString(class)
+				i += length
+			} else {
+				regex.WriteString(`\[`)
+				i++
+			}
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			// a backslash escapes the next character, ex: \# or \!
+			_, size := utf8.DecodeRuneInString(pattern[i+1:])
+			regex.WriteString(regexp.QuoteMeta(pattern[i+1 : i+1+size]))
+			i += 1 + size
+		default:
+			_, size := utf8.DecodeRuneInString(pattern[i:])
+			regex.WriteString(regexp.QuoteMeta(pattern[i : i+size]))
+			i += size
+		}
+	}
+	regex.WriteString("$")
+
+	compiled, err := regexp.Compile(regex.String())
+	if err != nil {
+		logger.Warn("Skipping ignore pattern '", line, "': ", err)
+		return IgnorePattern{}, false
+	}
+	ignorePattern.regex = compiled
+	return ignorePattern, true
+}
+
+// trailing spaces are not part of a pattern unless they are escaped with a backslash, ex: "name\ "
+func trimUnescapedTrailingSpaces(pattern string) string {
+	pattern = strings.TrimRight(pattern, "\r\n")
+	for strings.HasSuffix(pattern, " ") && !strings.HasSuffix(pattern, `\ `) {
+		pattern = pattern[:len(pattern)-1]
+	}
+	return pattern
+}
+
+// converts a bracket expression at the start of a glob, ex: [a-z], [!0-9] or [[:digit:]], into a class that never matches a /
+// Returns the class, the length of the bracket expression and false if the bracket is not closed
+func globCharacterClass(glob string) (string, int, bool) {
+	i := 1
+	isNegated := i < len(glob) && (glob[i] == '!' || glob[i] == '^')
+	if isNegated {
+		i++
+	}
+	class := strings.Builder{}
+	class.WriteString("[")
+	if isNegated {
+		class.WriteString("^/")
+	}
+	isEmpty := true
+	for isFirst := true; i < len(glob); isFirst = false {
+		c := glob[i]
+		switch {
+		case c == ']' && !isFirst:
+			if isEmpty {
+				return "", 0, false
+			}
+			class.WriteString("]")
+			return class.String(), i + 1, true
+		case c == '[' && strings.HasPrefix(glob[i:], "[:"):
+			// a character class name, ex: [:alpha:]
+			end := strings.Index(glob[i+2:], ":]")
+			if end == -1 {
+				return "", 0, false
+			}
+			class.WriteString(glob[i : i+2+end+2])
+			i += 2 + end + 2
+		case c == '\\' && i+1 < len(glob):
+			// the escaped character is matched literally, ex: [\d] matches a d and [a\-z] does not match a b
+			// letters and digits must not be escaped in the regular expression, that would turn \d into a digit class
+			escaped := glob[i+1]
+			i += 2
+			if escaped == '/' && !isNegated {
+				continue
+			}
+			if escaped < utf8.RuneSelf && !isWordCharacter(escaped) {
+				class.WriteString(`\`)
+			}
+			class.WriteByte(escaped)
+		case c == '/' && !isNegated:
+			// a / is never matched, paths are split by it
+			i++
+			continue
+		case c == '[' || c == ']' || c == '^' || c == '\\':
+			class.WriteString(`\`)
+			class.WriteByte(c)
+			i++
+		default:
+			class.WriteByte(c)
+			i++
+		}
+		isEmpty = false
+	}
+	return "", 0, false
+}
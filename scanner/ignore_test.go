@@ -0,0 +1,397 @@
+package scanner
+
+import (
+	"bytes"
+	"go-cloc/logger"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ignoreCase struct {
+	path    string // relative to the directory of the ignore file
+	isDir   bool
+	ignored bool
+}
+
+// checks each path against the patterns, the ignore file lives in a directory that does not need to exist
+func assertIgnored(t *testing.T, patterns []string, cases []ignoreCase) {
+	t.Helper()
+	ignoreFile := NewIgnoreFile("/repo", patterns)
+	for _, c := range cases {
+		absPath := filepath.Join(ignoreFile.BaseDir, filepath.FromSlash(c.path))
+		assert.Equal(t, c.ignored, IsIgnored([]IgnoreFile{ignoreFile}, absPath, c.isDir), "patterns %q path %q", patterns, c.path)
+	}
+}
+
+// examples from the PATTERN FORMAT section of the gitignore documentation
+func Test_ignore_gitignore_documentation_examples(t *testing.T) {
+	// Assert
+	// "hello.*" matches any file or directory whose name begins with hello.
+	assertIgnored(t, []string{"hello.*"}, []ignoreCase{
+		{"hello.c", false, true},
+		{"a/hello.java", false, true},
+		{"hello", false, false},
+		{"a/hello.d", true, true},
+	})
+	// "doc/frotz/" matches the doc/frotz directory, but not a/doc/frotz since the slash in the middle anchors it
+	assertIgnored(t, []string{"doc/frotz/"}, []ignoreCase{
+		{"doc/frotz", true, true},
+		{"a/doc/frotz", true, false},
+		{"doc/frotz", false, false},
+	})
+	// "frotz/" matches frotz and a/frotz that are directories
+	assertIgnored(t, []string{"frotz/"}, []ignoreCase{
+		{"frotz", true, true},
+		{"a/frotz", true, true},
+		{"frotz", false, false},
+	})
+	// "foo/" matches a directory foo and paths underneath it, but not a regular file foo
+	assertIgnored(t, []string{"foo/"}, []ignoreCase{
+		{"foo", true, true},
+		{"foo", false, false},
+		{"a/foo", true, true},
+	})
+	// "/*.c" matches cat-file.c but not mozilla-sha1/sha1.c
+	assertIgnored(t, []string{"/*.c"}, []ignoreCase{
+		{"cat-file.c", false, true},
+		{"mozilla-sha1/sha1.c", false, false},
+	})
+	// "foo/*" matches foo/test.json and foo/bar, but not foo/bar/hello.c
+	assertIgnored(t, []string{"foo/*"}, []ignoreCase{
+		{"foo/test.json", false, true},
+		{"foo/bar", true, true},
+		{"foo/bar/hello.c", false, false},
+		{"foo", true, false},
+	})
+	// "**/foo" matches file or directory foo anywhere
+	assertIgnored(t, []string{"**/foo"}, []ignoreCase{
+		{"foo", false, true},
+		{"a/foo", true, true},
+		{"a/b/foo", false, true},
+		{"a/foobar", false, false},
+	})
+	// "**/foo/bar" matches bar anywhere that is directly under a directory foo
+	assertIgnored(t, []string{"**/foo/bar"}, []ignoreCase{
+		{"foo/bar", false, true},
+		{"a/foo/bar", true, true},
+		{"a/foo/x/bar", false, false},
+	})
+	// "abc/**" matches all files inside the directory abc
+	assertIgnored(t, []string{"abc/**"}, []ignoreCase{
+		{"abc/x", false, true},
+		{"abc/x/y.c", false, true},
+		{"abc", true, false},
+		{"a/abc/x", false, false},
+	})
+	// "a/**/b" matches a/b, a/x/b, a/x/y/b and so on
+	assertIgnored(t, []string{"a/**/b"}, []ignoreCase{
+		{"a/b", false, true},
+		{"a/x/b", false, true},
+		{"a/x/y/b", false, true},
+		{"a/xb", false, false},
+		{"x/a/b", false, false},
+	})
+}
+
+// example from the gitignore documentation which excludes everything except the directory foo/bar
+func Test_ignore_negation_re_includes_paths(t *testing.T) {
+	patterns := []string{"/*", "!/foo", "/foo/*", "!/foo/bar"}
+
+	// Assert
+	assertIgnored(t, patterns, []ignoreCase{
+		{"README.md", false, true},
+		{"src", true, true},
+		{"foo", true, false},
+		{"foo/baz", true, true},
+		{"foo/main.go", false, true},
+		{"foo/bar", true, false},
+		{"foo/bar/main.go", false, false},
+	})
+	// the last matching pattern decides
+	assertIgnored(t, []string{"*.js", "!keep.js"}, []ignoreCase{
+		{"a.js", false, true},
+		{"keep.js", false, false},
+		{"src/keep.js", false, false},
+	})
+	assertIgnored(t, []string{"!keep.js", "*.js"}, []ignoreCase{
+		{"keep.js", false, true},
+	})
+}
+
+func Test_ignore_wildcards_and_character_classes(t *testing.T) {
+	// Assert
+	// asterisks and question marks do not match a slash
+	assertIgnored(t, []string{"src/*.go"}, []ignoreCase{
+		{"src/main.go", false, true},
+		{"src/cmd/main.go", false, false},
+	})
+	assertIgnored(t, []string{"file?.txt"}, []ignoreCase{
+		{"file1.txt", false, true},
+		{"file.txt", false, false},
+		{"file12.txt", false, false},
+	})
+	assertIgnored(t, []string{"a?b"}, []ignoreCase{
+		{"a/b", false, false},
+	})
+	assertIgnored(t, []string{"[a-zA-Z]*.log"}, []ignoreCase{
+		{"debug.log", false, true},
+		{"Debug.log", false, true},
+		{"1debug.log", false, false},
+	})
+	assertIgnored(t, []string{"log[!0-9].txt", "data[^a]"}, []ignoreCase{
+		{"loga.txt", false, true},
+		{"log1.txt", false, false},
+		{"datab", false, true},
+		{"dataa", false, false},
+	})
+	assertIgnored(t, []string{"v[[:digit:]]"}, []ignoreCase{
+		{"v1", false, true},
+		{"vx", false, false},
+	})
+	assertIgnored(t, []string{"[]]x", "[!]]y"}, []ignoreCase{
+		{"]x", false, true},
+		{"ay", false, true},
+		{"]y", false, false},
+	})
+	// a backslash in a bracket expression escapes the next character, it does not start a regular expression class
+	assertIgnored(t, []string{`[\d].txt`, `x[a\-c]`, `y[\]]`}, []ignoreCase{
+		{"d.txt", false, true},
+		{"5.txt", false, false},
+		{"\\.txt", false, false},
+		{"x-", false, true},
+		{"xa", false, true},
+		{"xb", false, false},
+		{"y]", false, true},
+	})
+	// an unclosed bracket is matched literally
+	assertIgnored(t, []string{"a[b"}, []ignoreCase{
+		{"a[b", false, true},
+		{"ab", false, false},
+	})
+	// other characters are not regular expressions
+	assertIgnored(t, []string{"a+b.(c)", "ünïcödé.txt"}, []ignoreCase{
+		{"a+b.(c)", false, true},
+		{"aab.(c)", false, false},
+		{"ünïcödé.txt", false, true},
+	})
+}
+
+func Test_ignore_comments_escapes_and_spaces(t *testing.T) {
+	// Assert
+	assertIgnored(t, []string{"# comment", "", `\#file`, `\!important`}, []ignoreCase{
+		{"# comment", false, false},
+		{"#file", false, true},
+		{"!important", false, true},
+	})
+	// trailing spaces are ignored unless escaped, leading spaces are part of the pattern
+	assertIgnored(t, []string{"trailing  ", `escaped\ `, " leading"}, []ignoreCase{
+		{"trailing", false, true},
+		{"escaped ", false, true},
+		{"escaped", false, false},
+		{" leading", false, true},
+		{"leading", false, false},
+	})
+	assertIgnored(t, []string{`star\*`, `\?`}, []ignoreCase{
+		{"star*", false, true},
+		{"stars", false, false},
+		{"?", false, true},
+		{"a", false, false},
+	})
+	assertIgnored(t, []string{"windows.txt\r"}, []ignoreCase{
+		{"windows.txt", false, true},
+	})
+}
+
+func Test_ignore_patterns_are_relative_to_the_ignore_file(t *testing.T) {
+	ignoreFile := NewIgnoreFile("/repo/sub", []string{"/build", "*.tmp"})
+
+	// Assert
+	assert.NotNil(t, ignoreFile.Match(filepath.Join(ignoreFile.BaseDir, "build"), true))
+	assert.NotNil(t, ignoreFile.Match(filepath.Join(ignoreFile.BaseDir, "a", "x.tmp"), false))
+	assert.Nil(t, ignoreFile.Match(filepath.Join(ignoreFile.BaseDir, "a", "build"), true))
+	// paths outside of the directory and the directory itself are never matched
+	assert.Nil(t, ignoreFile.Match(filepath.Join(ignoreFile.BaseDir, "..", "x.tmp"), false))
+	assert.Nil(t, ignoreFile.Match(filepath.Join(ignoreFile.BaseDir, "..", "subdir", "x.tmp"), false))
+	assert.Nil(t, ignoreFile.Match(ignoreFile.BaseDir, true))
+}
+
+func Test_ignore_later_files_take_precedence(t *testing.T) {
+	parent := NewIgnoreFile("/repo", []string{"*.js"})
+	child := NewIgnoreFile("/repo/web", []string{"!app.js"})
+
+	// Assert
+	assert.False(t, IsIgnored([]IgnoreFile{parent, child}, filepath.Join(child.BaseDir, "app.js"), false))
+	assert.True(t, IsIgnored([]IgnoreFile{parent, child}, filepath.Join(child.BaseDir, "lib.js"), false))
+	assert.True(t, IsIgnored([]IgnoreFile{child, parent}, filepath.Join(child.BaseDir, "app.js"), false))
+}
+
+func Test_ignore_WalkDirectory_with_ignore_file(t *testing.T) {
+	// test-ignore-file.txt ignores *.js and the misc directory, relative to the test-files directory
+	ignoreFiles := []IgnoreFile{LoadIgnoreFile("test-files/test-ignore-file.txt")}
+
+	result := WalkDirectory("test-files", ignoreFiles)
+
+	// Assert
+	all := WalkDirectory("test-files", []IgnoreFile{})
+	assert.Less(t, len(result), len(all))
+	for _, filePath := range result {
+		assert.NotEqual(t, ".js", filepath.Ext(filePath), filePath)
+		assert.NotContains(t, filepath.ToSlash(filePath), "/test-files/misc/")
+	}
+	assert.Contains(t, result, filepath.Join(ignoreFiles[0].BaseDir, "vue", "component.vue"))
+}
+
+func Test_ignore_WalkDirectory_negated_pattern(t *testing.T) {
+	ignoreFiles := []IgnoreFile{NewIgnoreFile("test-files", []string{"/*", "!/js"})}
+
+	result := WalkDirectory("test-files", ignoreFiles)
+
+	// Assert
+	assert.Equal(t, WalkDirectory("test-files/js", []IgnoreFile{}), result)
+}
+
+// creates the files in a temporary directory, the keys are slash separated relative paths
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(path))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		assert.Nil(t, os.WriteFile(fullPath, []byte(content), 0o644))
+	}
+	return root
+}
+
+// returns the slash separated paths of the walked files relative to the root
+func walkRelative(t *testing.T, root string, targetPath string) []string {
+	t.Helper()
+	absRoot, err := filepath.Abs(root)
+	assert.Nil(t, err)
+	relPaths := []string{}
+	for _, filePath := range WalkDirectory(targetPath, []IgnoreFile{}) {
+		relPath, err := filepath.Rel(absRoot, filePath)
+		assert.Nil(t, err)
+		relPaths = append(relPaths, filepath.ToSlash(relPath))
+	}
+	return relPaths
+}
+
+var repositoryTree = map[string]string{
+	".git/info/exclude":         "secret.js\n",
+	".git/hooks/check.py":       "print('hook')\n",
+	".gitignore":                "node_modules/\nbuild/\n*.min.js\n",
+	"app.js":                    "x = 1\n",
+	"secret.js":                 "x = 1\n",
+	"node_modules/lib/index.js": "x = 1\n",
+	"build/out.js":              "x = 1\n",
+	"src/.ignore":               "generated.js\n",
+	"src/.go-cloc-ignore":       "!keep.min.js\n",
+	"src/main.js":               "x = 1\n",
+	"src/generated.js":          "x = 1\n",
+	"src/app.min.js":            "x = 1\n",
+	"src/keep.min.js":           "x = 1\n",
+	"other/generated.js":        "x = 1\n",
+}
+
+func Test_ignore_WalkDirectory_discovers_ignore_files(t *testing.T) {
+	root := writeTree(t, repositoryTree)
+
+	result := walkRelative(t, root, root)
+
+	// Assert
+	// ignore files in src only apply inside src, the exclude file and .gitignore apply everywhere
+	assert.Equal(t, []string{"app.js", "other/generated.js", "src/keep.min.js", "src/main.js"}, result)
+}
+
+func Test_ignore_WalkDirectory_applies_ignore_files_of_parent_directories_in_the_repository(t *testing.T) {
+	root := writeTree(t, repositoryTree)
+
+	result := walkRelative(t, root, filepath.Join(root, "src"))
+
+	// Assert
+	assert.Equal(t, []string{"src/keep.min.js", "src/main.js"}, result)
+}
+
+func Test_ignore_WalkDirectory_without_vcs_ignore_files(t *testing.T) {
+	root := writeTree(t, repositoryTree)
+	UseVCSIgnoreFiles = false
+	defer func() { UseVCSIgnoreFiles = true }()
+
+	result := walkRelative(t, root, root)
+
+	// Assert
+	assert.Equal(t, []string{
+		".git/hooks/check.py",
+		"app.js",
+		"build/out.js",
+		"node_modules/lib/index.js",
+		"other/generated.js",
+		"secret.js",
+		"src/app.min.js",
+		"src/generated.js",
+		"src/keep.min.js",
+		"src/main.js",
+	}, result)
+}
+
+func Test_ignore_WalkDirectory_user_ignore_file_takes_precedence(t *testing.T) {
+	root := writeTree(t, repositoryTree)
+	absRoot, _ := filepath.Abs(root)
+
+	result := WalkDirectory(root, []IgnoreFile{NewIgnoreFile(root, []string{"!secret.js", "other/"})})
+
+	// Assert
+	assert.Contains(t, result, filepath.Join(absRoot, "secret.js"))
+	assert.NotContains(t, result, filepath.Join(absRoot, "other", "generated.js"))
+}
+
+func Test_ignore_WalkDirectory_matches_relative_to_the_scan_root(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"config/ignore.txt":  "/main.js\nlib/\n",
+		"src/main.js":        "x = 1\n",
+		"src/app.js":         "x = 1\n",
+		"src/lib/util.js":    "x = 1\n",
+		"src/nested/main.js": "x = 1\n",
+	})
+	absSrc, err := filepath.Abs(filepath.Join(root, "src"))
+	assert.Nil(t, err)
+	workingDir, err := os.Getwd()
+	assert.Nil(t, err)
+	relSrc, err := filepath.Rel(workingDir, absSrc)
+	assert.Nil(t, err)
+	// the ignore file is outside of the scan root, so its patterns are anchored to the scan root
+	ignoreFiles := []IgnoreFile{LoadIgnoreFile(filepath.Join(root, "config", "ignore.txt"))}
+
+	// Assert
+	expected := []string{filepath.Join(absSrc, "app.js"), filepath.Join(absSrc, "nested", "main.js")}
+	for _, targetPath := range []string{absSrc, absSrc + string(filepath.Separator), relSrc, "." + string(filepath.Separator) + relSrc} {
+		assert.Equal(t, expected, WalkDirectory(targetPath, ignoreFiles), targetPath)
+	}
+}
+
+func Test_ignore_WalkDirectory_logs_the_rule_that_excluded_a_path(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore": "# build output\nbuild/\n*.min.js\n",
+		"app.js":     "x = 1\n",
+		"app.min.js": "x = 1\n",
+		"build/a.js": "x = 1\n",
+	})
+	output := bytes.Buffer{}
+	logger.SetOutput(&output)
+	logger.SetLogLevel(logger.DEBUG)
+	defer func() {
+		logger.SetOutput(os.Stderr)
+		logger.SetLogLevel(logger.INFO)
+	}()
+
+	WalkDirectory(root, []IgnoreFile{})
+
+	// Assert
+	gitignorePath := filepath.Join(root, ".gitignore")
+	assert.Contains(t, output.String(), "'build/' from "+gitignorePath+":2")
+	assert.Contains(t, output.String(), "'*.min.js' from "+gitignorePath+":3")
+	assert.NotContains(t, output.String(), "app.js  - ignored")
+}
@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"unsafe"
+)
+
+type LineEnding string
+
+const (
+	LineEndingLF    LineEnding = "LF"
+	LineEndingCRLF  LineEnding = "CRLF"
+	LineEndingCR    LineEnding = "CR"
+	LineEndingMixed LineEnding = "mixed"
+	LineEndingNone  LineEnding = "none" // the file has at most one line, which is not terminated
+)
+
+// number of bytes of a line that are held in memory at once, longer lines are read in chunks of this size
+const LineChunkBytes = 1024 * 1024
+
+// LineReader splits text into physical lines ending in \n, \r\n or a lone \r, ex: classic Mac OS files
+type LineReader struct {
+	reader     *bufio.Reader
+	line       []byte // reused for every line, so reading a line does not allocate once it has grown to the longest line
+	chunkBytes int    // maximum length of a chunk returned by ReadLineChunk
+	inLine     bool   // the last chunk did not end its line
+	lfCount    int
+	crlfCount  int
+	crCount    int
+}
+
+func NewLineReader(reader *bufio.Reader) *LineReader {
+	return &LineReader{reader: reader, chunkBytes: LineChunkBytes}
+}
+
+// ReadLine returns the next line without its line ending
+// A last line without a line ending is still a line, while a line ending right before the end of the input does not start another one
+// Returns io.EOF once there are no more lines
+func (lineReader *LineReader) ReadLine() (string, error) {
+	line, err := lineReader.ReadLineBytes()
+	return string(line), err
+}
+
+// ReadLineBytes is ReadLine without the copy into a string
+// The whole line is held in memory, use ReadLineChunk for input that may hold very long lines
+// The returned slice is only valid until the next call, the caller may modify it in place
+func (lineReader *LineReader) ReadLineBytes() ([]byte, error) {
+	line, _, err := lineReader.readLine(nil, math.MaxInt)
+	return line, err
+}
+
+// ReadLineChunk returns the next part of the current line, or of the next line once the current one has ended
+// The carry is returned again at the start of the chunk, ex: the start of a token that was cut off by the end of the previous chunk
+// Besides the carry a chunk holds at most LineChunkBytes, so memory use does not depend on the length of the line
+// Returns whether the chunk ends its line. The returned slice is only valid until the next call, the caller may modify it in place
+func (lineReader *LineReader) ReadLineChunk(carry []byte) ([]byte, bool, error) {
+	return lineReader.readLine(carry, len(carry)+lineReader.chunkBytes)
+}
+
+// reads the next line or part of a line into the line buffer, stopping at the line ending or once the buffer holds the limit
+func (lineReader *LineReader) readLine(carry []byte, limit int) ([]byte, bool, error) {
+	// the carry may point into the line buffer, copy handles the overlap
+	lineReader.line = lineReader.line[:len(carry)]
+	copy(lineReader.line, carry)
+	// the rest of a line that was cut off is still part of that line, even when the input ends
+	hasContent := lineReader.inLine
+	lineReader.inLine = false
+	for {
+		if len(lineReader.line) >= limit {
+			lineReader.inLine = true
+			return lineReader.line, false, nil
+		}
+		buffered, err := lineReader.reader.Peek(max(lineReader.reader.Buffered(), 1))
+		if len(buffered) == 0 {
+			if hasContent {
+				return lineReader.line, true, nil
+			}
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, true, err
+		}
+		hasContent = true
+		buffered = buffered[:min(len(buffered), limit-len(lineReader.line))]
+
+		endIndex := indexOfLineEnding(buffered)
+		if endIndex == -1 {
+			lineReader.line = append(lineReader.line, buffered...)
+			lineReader.reader.Discard(len(buffered))
+			continue
+		}
+		lineReader.line = append(lineReader.line, buffered[:endIndex]...)
+		lineEnding := buffered[endIndex]
+		lineReader.reader.Discard(endIndex + 1)
+		if lineEnding == '\n' {
+			lineReader.lfCount++
+			return lineReader.line, true, nil
+		}
+		// \r may be followed by \n, which belongs to the same line ending
+		next, err := lineReader.reader.Peek(1)
+		if err == nil && next[0] == '\n' {
+			lineReader.reader.Discard(1)
+			lineReader.crlfCount++
+		} else {
+			lineReader.crCount++
+		}
+		return lineReader.line, true, nil
+	}
+}
+
+// returns the index of the first \r or \n, or -1 if there is none
+// \n is searched for with the vectorized bytes.IndexByte, as \r is rare outside of Windows files
+func indexOfLineEnding(buffered []byte) int {
+	endIndex := bytes.IndexByte(buffered, '\n')
+	if endIndex == -1 {
+		endIndex = len(buffered)
+	}
+	if crIndex := bytes.IndexByte(buffered[:endIndex], '\r'); crIndex != -1 {
+		return crIndex
+	}
+	if endIndex == len(buffered) {
+		return -1
+	}
+	return endIndex
+}
+
+// viewAsString returns the bytes as a string without copying them
+// The string must not be used after the bytes change, ex: it must not outlive the next call to ReadLineBytes
+func viewAsString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// LineEnding returns the line ending style of the lines read so far
+func (lineReader *LineReader) LineEnding() LineEnding {
+	styles := []struct {
+		count      int
+		lineEnding LineEnding
+	}{
+		{lineReader.lfCount, LineEndingLF},
+		{lineReader.crlfCount, LineEndingCRLF},
+		{lineReader.crCount, LineEndingCR},
+	}
+	found := LineEndingNone
+	for _, style := range styles {
+		if style.count == 0 {
+			continue
+		}
+		if found != LineEndingNone {
+			return LineEndingMixed
+		}
+		found = style.lineEnding
+	}
+	return found
+}
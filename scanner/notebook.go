@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"encoding/json"
+	"go-cloc/logger"
+	"io"
+	"strings"
+)
+
+// name of the language whose files are parsed as Jupyter notebooks instead of line by line
+const NotebookLanguageName = "Jupyter Notebook"
+
+// notebook holds the parts of a Jupyter notebook (nbformat 4) that are counted, outputs and all other metadata are ignored
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		KernelSpec struct {
+			Name     string `json:"name"`
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string          `json:"cell_type"`
+	Source   json.RawMessage `json:"source"` // a single string or a list of lines which keep their line breaks
+}
+
+// splits the source of a cell into lines
+func (cell notebookCell) lines() ([]string, error) {
+	if len(cell.Source) == 0 {
+		return []string{}, nil
+	}
+	source := ""
+	sourceLines := []string{}
+	if err := json.Unmarshal(cell.Source, &sourceLines); err == nil {
+		source = strings.Join(sourceLines, "")
+	} else if err := json.Unmarshal(cell.Source, &source); err != nil {
+		return nil, err
+	}
+	if source == "" {
+		return []string{}, nil
+	}
+	return strings.Split(strings.TrimSuffix(source, "\n"), "\n"), nil
+}
+
+// finds the language of the code cells from the notebook metadata, ex: "python" or a kernel name such as "python3"
+// Falls back to the notebook itself, without any comment syntax, when the kernel language is not configured
+func notebookKernelLanguage(nb notebook) (string, LanguageInfo) {
+	candidates := []string{nb.Metadata.LanguageInfo.Name, nb.Metadata.KernelSpec.Language, nb.Metadata.KernelSpec.Name}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if lang, info, found := LookupByModeline(candidate); found {
+			return lang, info
+		}
+	}
+	logger.Debug("Kernel language ", candidates, " is not supported, counting code cells as ", NotebookLanguageName)
+	return NotebookLanguageName, Languages[NotebookLanguageName]
+}
+
+// counts the lines of a Jupyter notebook
+// Code cells are counted with the kernel language, markdown cells as comments. Outputs and raw cells are ignored
+func scanNotebook(reader io.Reader, result FileScanResults, langName string, detectionRule string) FileScanResults {
+	result.LanguageName = langName
+	result.DetectionRule = detectionRule
+
+	nb := notebook{}
+	if err := json.NewDecoder(reader).Decode(&nb); err != nil {
+		logger.Debug("Skipping file: ", result.FilePath, ". Invalid notebook: ", err)
+		result.SkipReason = "invalid notebook: " + err.Error()
+		return result
+	}
+	kernelName, kernelInfo := notebookKernelLanguage(nb)
+
+	result.LanguageToCodeLineCount = map[string]int{}
+	for _, cell := range nb.Cells {
+		lines, err := cell.lines()
+		if err != nil {
+			logger.Debug("Skipping file: ", result.FilePath, ". Invalid notebook cell source: ", err)
+			return FileScanResults{FilePath: result.FilePath, LanguageName: langName, DetectionRule: detectionRule, SkipReason: "invalid notebook: " + err.Error()}
+		}
+		switch cell.CellType {
+		case "code":
+			// each cell is lexed on its own, a cell can not leave a comment or string open for the next
+			state := LineState{}
+			for _, line := range lines {
+				var lineResult AnalyzeLineResult
+				lineResult, state = AnalyzeLine(strings.TrimSpace(line), kernelInfo, state)
+				result.countLine(lineResult, kernelName)
+				result.TotalLines++
+			}
+		case "markdown":
+			for _, line := range lines {
+				if strings.TrimSpace(line) == "" {
+					result.countLine(BlankLine, kernelName)
+				} else {
+					result.countLine(Comment, kernelName)
+				}
+				result.TotalLines++
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ScanDirectoriesToChannel walks the directories and scans the files found with a pool of workers, workers below 1 use one worker per GOMAXPROCS
+// Files are scanned while the directories are still being walked and results are sent in the order the files finish
+// A file reachable from several directories is only scanned the first time it is found
+// Files left out by the Filter are sent as skipped results without being scanned, the returned channel is closed once all files are sent
+func ScanDirectoriesToChannel(targetPaths []string, ignoreFiles []IgnoreFile, workers int) <-chan FileScanResults {
+	files := make(chan FileScanResults)
+	go walkDirectoriesToChannel(targetPaths, ignoreFiles, files)
+	return scanFileResultsFromChannel(files, workers)
+}
+
+// scans the files of the results sent on the channel, results which already have a SkipReason are passed on as they are
+func scanFileResultsFromChannel(files <-chan FileScanResults, workers int) <-chan FileScanResults {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	fileScanResults := make(chan FileScanResults, workers)
+	waitGroup := sync.WaitGroup{}
+	for range workers {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for file := range files {
+				if file.SkipReason != "" {
+					fileScanResults <- file
+					continue
+				}
+				fileScanResults <- scanFile(file)
+			}
+		}()
+	}
+	go func() {
+		waitGroup.Wait()
+		close(fileScanResults)
+	}()
+
+	return fileScanResults
+}
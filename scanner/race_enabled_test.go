@@ -0,0 +1,6 @@
+//go:build race
+
+package scanner
+
+// the race detector changes the allocations of the code under test, ex: sync.Pool drops items at random
+const raceEnabled = true
@@ -2,13 +2,20 @@ package scanner
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"go-cloc/logger"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 )
 
 type FileScanResults struct {
@@ -18,6 +25,17 @@ type FileScanResults struct {
 	CodeLineCount     int
 	BlankLineCount    int
 	CommentsLineCount int
+	MixedLineCount    int    // lines with both code and a comment, these are also counted according to CountMixedLinesAs
+	DataLineCount     int    // lines longer than MaxLineLength, these are only counted in TotalLines
+	DetectionRule     string // how the language was chosen, ex: "extension .go" or "heuristic #import for .h"
+	SkipReason        string // why the file was not counted, ex: "binary: NUL bytes". Empty when the file was scanned
+	IsGenerated       bool   // file was written by a tool or minified, its lines are still counted but reported separately
+	GeneratedReason   string // why the file is treated as generated, ex: "generated: @generated marker" or "minified: average line length 165"
+
+	LanguageToCodeLineCount map[string]int // code lines by language, files with embedded languages count each block under its own language
+	Encoding                Encoding       // encoding the file was decoded from, ex: "UTF-16LE"
+	LineEnding              LineEnding     // line ending style of the file, ex: "CRLF" or "mixed"
+	RealPath                string         // path of the file with the symbolic links resolved when it was reached through a link, empty otherwise
 }
 type AnalyzeLineResult string
 
@@ -25,34 +43,763 @@ const (
 	Code      AnalyzeLineResult = "code"
 	Comment   AnalyzeLineResult = "comment"
 	BlankLine AnalyzeLineResult = "blankline"
+	Mixed     AnalyzeLineResult = "mixed" // code and a comment on the same line, ex: x++; // increment
+	DataLine  AnalyzeLineResult = "data"  // line longer than MaxLineLength, it is not lexed and not counted as code, comment or blank
+)
+
+// lines longer than this many bytes are counted as data instead of being lexed, set by the --max-line-length CLI argument. 0 means no limit
+var MaxLineLength = 0
+
+type MixedLinePolicy string
+
+// Policies for counting mixed lines
+const (
+	MixedAsCode    MixedLinePolicy = "code"
+	MixedAsComment MixedLinePolicy = "comment"
+	MixedAsBoth    MixedLinePolicy = "both"
 )
 
-func AnalyzeLine(line string, languageInfo LanguageInfo, isInBlockComment bool) (AnalyzeLineResult, bool) {
+// CountMixedLinesAs decides whether mixed lines add to the code count, the comment count or both
+var CountMixedLinesAs = MixedAsCode
+
+// ParseMixedLinePolicy converts a CLI value into a MixedLinePolicy, returns false if the value is not supported
+func ParseMixedLinePolicy(policy string) (MixedLinePolicy, bool) {
+	switch MixedLinePolicy(strings.ToLower(policy)) {
+	case MixedAsCode:
+		return MixedAsCode, true
+	case MixedAsComment:
+		return MixedAsComment, true
+	case MixedAsBoth:
+		return MixedAsBoth, true
+	}
+	return "", false
+}
+
+// LineState is the lexer state carried over from the end of one line to the start of the next
+type LineState struct {
+	BlockCommentDepth int    // number of multi-line comments still open, only languages with NestedComments go deeper than 1
+	BlockCommentIndex int    // index of the MultiLineComments pair that opened the comment, only its closer can end it
+	OpenString        string // closing delimiter of a multi-line string literal that is still open
+	OpenDocString     bool   // the open multi-line string literal is a docstring, its lines are documentation
+	BracketDepth      int    // brackets still open, only tracked for languages with DocStringDelimiters
+	LineContinues     bool   // the line ended with a \ so the next line continues the same statement
+}
+
+// InBlockComment reports whether a multi-line comment is still open
+func (state LineState) InBlockComment() bool {
+	return state.BlockCommentDepth > 0
+}
+
+// AnalyzeLine classifies a single trimmed line. The line is lexed from left to right so comment
+// markers that appear inside string, char or template literals are ignored. The returned state
+// must be passed in when analyzing the next line of the same file.
+func AnalyzeLine(line string, languageInfo LanguageInfo, state LineState) (AnalyzeLineResult, LineState) {
+	syntax := newLexerSyntax(&languageInfo)
+	return analyzeLine(line, &languageInfo, &syntax, state)
+}
 
-	if isInBlockComment || beginsWithFirstMultiLineComment(line, languageInfo) {
-		// last characters on the line end a multi-line comment
-		if endsWithSecondMultiLineComment(line, languageInfo) {
-			return Comment, false
+// analyzeLine is AnalyzeLine for the language of a file, whose syntax is set up once rather than for every line
+// A whole line is lexed in a single pass, only lines longer than a chunk go through scanLongLine
+func analyzeLine(line string, languageInfo *LanguageInfo, syntax *lexerSyntax, state LineState) (AnalyzeLineResult, LineState) {
+	// most lines hold no byte that may start a token or are a single-line comment, those skip setting up the lexer
+	if !state.InBlockComment() && state.OpenString == "" {
+		tokenIndex := syntax.tokenStarts.indexIn(line, 0)
+		// languages with docstrings follow the brackets of every line of code, so code always goes through the lexer
+		if tokenIndex == len(line) && !syntax.tracksStatements {
+			if line == "" {
+				return BlankLine, state
+			}
+			if !unicode.IsSpace(rune(line[0])) {
+				return Code, state
+			}
+		}
+		if tokenIndex == 0 && hasSingleLineComment(line, languageInfo, true) {
+			if _, found := beginsWithMultiLineComment(line, languageInfo); !found {
+				state.LineContinues = false
+				return Comment, state
+			}
 		}
-		// the multi-line comment continues
-		if !hasSecondMultiLineComment(line, languageInfo) {
-			return Comment, true
+	}
+	var lexer lineLexer
+	lexer.start(syntax, state)
+	lexer.lex(line, true, languageInfo)
+	return lexer.finish()
+}
+
+// lexerSyntax is what the lexer needs to know about a language besides its tokens, it is the same for every line of a file
+type lexerSyntax struct {
+	tokenStarts      byteSet // most bytes can not start a comment or string literal, those skip the checks for every token of the language
+	maxTokenLength   int     // only used when a line is lexed in chunks
+	tracksStatements bool
+	tracksRegex      bool
+}
+
+func newLexerSyntax(languageInfo *LanguageInfo) lexerSyntax {
+	return lexerSyntax{
+		tokenStarts:      tokenStartBytes(languageInfo),
+		maxTokenLength:   maxTokenLength(languageInfo),
+		tracksStatements: len(languageInfo.DocStringDelimiters) > 0,
+		tracksRegex:      len(languageInfo.RegexDelimiters) > 0,
+	}
+}
+
+// lineLexer classifies a line from left to right. A line that is too long to hold in memory at once may be lexed in chunks
+type lineLexer struct {
+	lexerSyntax
+	isStatementStart bool // a docstring must be the first thing in a statement, not part of an expression spanning multiple lines
+	state            LineState
+	hasCode          bool
+	hasComment       bool
+
+	// only used for languages with RegexDelimiters, a delimiter opens a literal only where an operand is expected
+	regexAllowed bool     // the last code on the line was an operator or punctuation such as ( or =
+	word         [10]byte // start of the last word of code, long enough for every keyword in regexPrecedingKeywords
+	wordLength   int
+	inWord       bool
+
+	// only used when the line is lexed in chunks
+	lineDone                  bool // the rest of the line is not lexed, ex: it is a single-line comment
+	openLineString            bool // state.OpenString is a single-line literal, which ends with the line
+	openDocStringOnLine       bool // the open docstring opened on this line, code after it on the same line makes it an expression
+	pendingDocString          bool // a docstring closed at the end of a chunk, code after it on the same line makes it an expression
+	hasCommentBeforeDocString bool
+	previous                  byte   // the last byte of the previous chunk
+	openRegex                 string // closing delimiter of a regular expression literal that is still open, these end with the line
+	inRegexClass              bool   // the open regular expression is within a character class, ex: [/], where its delimiter does not close it
+}
+
+// start sets the lexer up for a new line, the lexer is reset in place rather than returned so it is not copied for every line
+func (lexer *lineLexer) start(syntax *lexerSyntax, state LineState) {
+	*lexer = lineLexer{
+		lexerSyntax:      *syntax,
+		regexAllowed:     true,
+		isStatementStart: state.BracketDepth == 0 && !state.LineContinues,
+		state:            state,
+		hasComment:       state.InBlockComment() || state.OpenDocString,
+	}
+	lexer.state.LineContinues = false
+}
+
+// lex classifies the next chunk of the line, isLineEnd is set for the last chunk or a whole line
+// Tokens that may be cut off by the end of a chunk are not lexed yet, returns the number of bytes consumed
+// The rest of the chunk must be passed again at the start of the next chunk
+// The language is passed in rather than kept in the lexer, which lets the lexer stay on the stack
+func (lexer *lineLexer) lex(line string, isLineEnd bool, languageInfo *LanguageInfo) int {
+	state := &lexer.state
+	// tokens starting at or after the limit may continue in the next chunk
+	limit := len(line)
+	if !isLineEnd {
+		limit = len(line) - lexer.maxTokenLength + 1
+	}
+
+	i := 0
+	for i < limit && !lexer.lineDone {
+		rest := line[i:]
+		if lexer.tracksStatements && !unicode.IsSpace(rune(line[i])) {
+			state.LineContinues = false
+		}
+
+		// inside a multi-line comment only the closing token matters, or the opening token again if the comment nests
+		if state.InBlockComment() {
+			index, tokenLength := indexOfSecondMultiLineComment(rest, languageInfo, state.BlockCommentIndex)
+			nestedIndex, nestedTokenLength := indexOfNestedMultiLineComment(rest, languageInfo, state.BlockCommentIndex)
+			if nestedIndex >= 0 && (index < 0 || nestedIndex < index) {
+				state.BlockCommentDepth++
+				i += nestedIndex + nestedTokenLength
+				continue
+			}
+			if index < 0 {
+				i = max(i, limit)
+				break
+			}
+			state.BlockCommentDepth--
+			if state.BlockCommentDepth == 0 {
+				state.BlockCommentIndex = 0
+			}
+			i += index + tokenLength
+			continue
+		}
+
+		// inside a multi-line string literal everything up to the closing delimiter is code, or documentation for docstrings
+		if state.OpenString != "" {
+			if state.OpenDocString {
+				lexer.hasComment = true
+			} else {
+				lexer.hasCode = true
+			}
+			end, scanned := indexOfStringEnd(rest, state.OpenString, languageInfo, limit-i)
+			if end < 0 {
+				i += scanned
+				break
+			}
+			state.OpenString = ""
+			state.OpenDocString = false
+			lexer.openLineString = false
+			if lexer.openDocStringOnLine {
+				lexer.openDocStringOnLine = false
+				lexer.endDocStringOnLine(line, i+end, limit, isLineEnd, languageInfo)
+			}
+			i += end
+			continue
+		}
+
+		// inside a regular expression literal comment markers and string delimiters are part of the pattern
+		if lexer.openRegex != "" {
+			end, scanned := lexer.indexOfRegexEnd(rest, lexer.openRegex, languageInfo, limit-i)
+			if end < 0 {
+				i += scanned
+				break
+			}
+			lexer.openRegex = ""
+			lexer.endOperand()
+			i += end
+			continue
+		}
+
+		// a docstring that closed at the end of the previous chunk must not be followed by code
+		previous := lexer.previous
+		if i > 0 {
+			previous = line[i-1]
+		}
+		if lexer.pendingDocString && !unicode.IsSpace(rune(line[i])) {
+			lexer.pendingDocString = false
+			if !hasSingleLineComment(rest, languageInfo, isWordStart(previous)) {
+				lexer.rejectDocString()
+			}
+		}
+
+		if lexer.tokenStarts.contains(line[i]) {
+			// comments may open anywhere on the line, even after code, and carry over to the next line
+			if pairIndex, found := beginsWithMultiLineComment(rest, languageInfo); found {
+				lexer.hasComment = true
+				state.BlockCommentDepth = 1
+				state.BlockCommentIndex = pairIndex
+				i += len(languageInfo.MultiLineComments[pairIndex][0])
+				continue
+			}
+			if hasSingleLineComment(rest, languageInfo, isWordStart(previous)) {
+				lexer.hasComment = true
+				lexer.lineDone = true
+				break
+			}
+
+			// a label or lifetime is code, its delimiter must not open a literal, ex: fn f<'a>() { /* c */ }
+			if labelLength := beginsWithLabel(rest, languageInfo); labelLength > 0 {
+				lexer.hasCode = true
+				i += labelLength
+				continue
+			}
+
+			// a regular expression can only be where an operand is expected, otherwise its delimiter is division, ex: a / b
+			if delimiter := beginsWithRegex(rest, languageInfo); delimiter != "" && lexer.isOperandExpected() {
+				lexer.hasCode = true
+				lexer.inRegexClass = false
+				end, scanned := lexer.indexOfRegexEnd(rest[len(delimiter):], delimiter, languageInfo, limit-i-len(delimiter))
+				if end < 0 {
+					if !isLineEnd {
+						// the literal may still be closed in the next chunk
+						lexer.openRegex = delimiter
+						i += len(delimiter) + scanned
+						break
+					}
+					lexer.lineDone = true
+					break
+				}
+				lexer.endOperand()
+				i += len(delimiter) + end
+				continue
+			}
+
+			// string prefixes such as r or f only count at the start of a word, ex: r"..." but not bar"..."
+			if opener, closer, multiLine, found := beginsWithStringLiteral(rest, languageInfo, !isWordCharacter(previous)); found {
+				end, scanned := indexOfStringEnd(rest[len(opener):], closer, languageInfo, limit-i-len(opener))
+				isDocString := multiLine && !lexer.hasCode && lexer.isStatementStart && isDocStringDelimiter(opener, languageInfo)
+				if isDocString {
+					lexer.hasCommentBeforeDocString = lexer.hasComment
+					lexer.hasComment = true
+					if end >= 0 {
+						lexer.endDocStringOnLine(line, i+len(opener)+end, limit, isLineEnd, languageInfo)
+					}
+				} else {
+					lexer.hasCode = true
+				}
+				lexer.endOperand()
+				if end < 0 {
+					if !isLineEnd {
+						// the literal may still be closed in the next chunk
+						state.OpenString = closer
+						state.OpenDocString = isDocString
+						lexer.openLineString = !multiLine
+						lexer.openDocStringOnLine = isDocString
+						i += len(opener) + scanned
+						break
+					}
+					// single line literals end with the line, even if they were never closed
+					if multiLine {
+						state.OpenString = closer
+						state.OpenDocString = isDocString
+					}
+					lexer.lineDone = true
+					break
+				}
+				i += len(opener) + end
+				continue
+			}
+		}
+
+		// bytes which can not start a token are taken in bulk up to the next byte which may start one
+		end := i + 1
+		if !lexer.pendingDocString {
+			end = lexer.tokenStarts.indexIn(line[:limit], end)
+		}
+		lexer.followCode(line[i:end])
+		i = end
+	}
+
+	if lexer.lineDone {
+		i = len(line)
+	}
+	if i > 0 {
+		lexer.previous = line[i-1]
+	}
+	return i
+}
+
+// a docstring that closes on the line it opened on must not be followed by code, ex: """text""".strip() is an expression
+// @index is the index just past the closing delimiter. When the rest of the line is in the next chunk the decision waits for it
+func (lexer *lineLexer) endDocStringOnLine(line string, index int, limit int, isLineEnd bool, languageInfo *LanguageInfo) {
+	remainder := strings.TrimLeftFunc(line[index:], unicode.IsSpace)
+	if !isLineEnd && len(line)-len(remainder) >= limit {
+		lexer.pendingDocString = true
+		return
+	}
+	if remainder != "" && !hasSingleLineComment(remainder, languageInfo, isWordStart(line[len(line)-len(remainder)-1])) {
+		lexer.rejectDocString()
+	}
+}
+
+// bytes of code after which an operand is expected, a regular expression may follow them
+const regexPrecedingCharacters = "(,=:[!&|?{};+-*%<>~^"
+
+// keywords after which an operand is expected, ex: return /a/.test(s)
+var regexPrecedingKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true, "delete": true,
+	"void": true, "throw": true, "case": true, "do": true, "else": true, "yield": true, "await": true,
+}
+
+// followCode records a run of code which holds no token, ex: the bytes between two string literals
+// It keeps track of the open brackets of a statement and of the last code on the line, which decides whether a regular expression may follow
+func (lexer *lineLexer) followCode(code string) {
+	if lexer.tracksStatements {
+		for i := 0; i < len(code); i++ {
+			switch code[i] {
+			case '(', '[', '{':
+				lexer.state.BracketDepth++
+			case ')', ']', '}':
+				if lexer.state.BracketDepth > 0 {
+					lexer.state.BracketDepth--
+				}
+			}
+			if !unicode.IsSpace(rune(code[i])) {
+				lexer.state.LineContinues = code[i] == '\\'
+			}
+		}
+	}
+
+	last := len(code) - 1
+	for last >= 0 && unicode.IsSpace(rune(code[last])) {
+		last--
+	}
+	if last < 0 {
+		lexer.inWord = false
+		return
+	}
+	lexer.hasCode = true
+	if !lexer.tracksRegex {
+		return
+	}
+	if isWordCharacter(code[last]) {
+		// a word at the start of the run continues the word before it, ex: a chunk ending in ret and the next starting with urn
+		start := last
+		for start > 0 && isWordCharacter(code[start-1]) {
+			start--
+		}
+		if start > 0 || !lexer.inWord {
+			lexer.wordLength = 0
+		}
+		for i := start; i <= last; i++ {
+			if lexer.wordLength < len(lexer.word) {
+				lexer.word[lexer.wordLength] = code[i]
+			}
+			lexer.wordLength++
+		}
+		lexer.regexAllowed = false
+	} else {
+		lexer.wordLength = 0
+		lexer.regexAllowed = strings.IndexByte(regexPrecedingCharacters, code[last]) >= 0
+	}
+	lexer.inWord = last == len(code)-1 && isWordCharacter(code[last])
+}
+
+// endOperand records that a literal ended, a delimiter right after it is division, ex: "a" / 2
+func (lexer *lineLexer) endOperand() {
+	lexer.inWord = false
+	lexer.wordLength = 0
+	lexer.regexAllowed = false
+}
+
+// checks whether the last code on the line expects an operand to follow, the start of a line does
+func (lexer *lineLexer) isOperandExpected() bool {
+	if lexer.regexAllowed {
+		return true
+	}
+	// a word longer than the buffer is not one of the keywords
+	return lexer.wordLength > 0 && lexer.wordLength <= len(lexer.word) && regexPrecedingKeywords[string(lexer.word[:lexer.wordLength])]
+}
+
+// returns the index just past the closing delimiter of a regular expression literal, skipping escaped characters and
+// character classes, or -1 if the literal is not closed before the limit. Also returns how far the line was scanned
+// @line is the remainder of the line after the opening delimiter
+func (lexer *lineLexer) indexOfRegexEnd(line string, closingDelimiter string, languageInfo *LanguageInfo, limit int) (int, int) {
+	i := 0
+	for i < len(line) && i < limit {
+		if escapeCharacter := beginsWithEscapeCharacter(line[i:], languageInfo); escapeCharacter != "" {
+			i += len(escapeCharacter) + 1
+			continue
+		}
+		switch {
+		case line[i] == '[':
+			lexer.inRegexClass = true
+		case line[i] == ']':
+			lexer.inRegexClass = false
+		case !lexer.inRegexClass && strings.HasPrefix(line[i:], closingDelimiter):
+			return i + len(closingDelimiter), i
+		}
+		i++
+	}
+	return -1, min(i, len(line))
+}
+
+// counts a string literal that looked like a docstring as code after all
+func (lexer *lineLexer) rejectDocString() {
+	lexer.hasComment = lexer.hasCommentBeforeDocString
+	lexer.hasCode = true
+}
+
+// finish ends the line and returns its result and the state to pass in for the next line
+func (lexer *lineLexer) finish() (AnalyzeLineResult, LineState) {
+	if lexer.openLineString {
+		lexer.state.OpenString = ""
+		lexer.state.OpenDocString = false
+	}
+	if lexer.hasCode && lexer.hasComment {
+		return Mixed, lexer.state
+	} else if lexer.hasCode {
+		return Code, lexer.state
+	} else if lexer.hasComment {
+		return Comment, lexer.state
+	}
+	return BlankLine, lexer.state
+}
+
+// scanLongLine classifies a line that does not fit into one chunk, the line is lexed chunk by chunk so memory use does not depend on its length
+// Tags of embedded languages are not looked for, the whole line belongs to the language it starts in
+// Returns the result, the state for the next line and the length of the line
+func scanLongLine(lineReader *LineReader, firstChunk []byte, isColumnComment bool, languageInfo *LanguageInfo, state LineState) (AnalyzeLineResult, LineState, int, error) {
+	length := len(firstChunk)
+	if isColumnComment || (MaxLineLength > 0 && length > MaxLineLength) {
+		lineResult := DataLine
+		if isColumnComment {
+			lineResult = Comment
+		}
+		skippedLength, err := skipRestOfLine(lineReader)
+		return lineResult, state, length + skippedLength, err
+	}
+
+	syntax := newLexerSyntax(languageInfo)
+	var lexer lineLexer
+	lexer.start(&syntax, state)
+	chunk := firstChunk
+	isLineEnd := false
+	for {
+		consumed := lexer.lex(viewAsString(chunk), isLineEnd, languageInfo)
+		if isLineEnd {
+			break
+		}
+		if MaxLineLength > 0 && length > MaxLineLength {
+			// the line turned out to be data, the state the lexer is in is discarded
+			skippedLength, err := skipRestOfLine(lineReader)
+			return DataLine, state, length + skippedLength, err
+		}
+		carry := chunk[consumed:]
+		var err error
+		chunk, isLineEnd, err = lineReader.ReadLineChunk(carry)
+		if err != nil {
+			return "", state, length, err
+		}
+		length += len(chunk) - len(carry)
+	}
+	if MaxLineLength > 0 && length > MaxLineLength {
+		return DataLine, state, length, nil
+	}
+	lineResult, state := lexer.finish()
+	return lineResult, state, length, nil
+}
+
+// reads the rest of a line that was cut off by the end of a chunk without looking at it
+// Returns the length of the rest of the line
+func skipRestOfLine(lineReader *LineReader) (int, error) {
+	length := 0
+	for {
+		chunk, isLineEnd, err := lineReader.ReadLineChunk(nil)
+		if err != nil {
+			return length, err
+		}
+		length += len(chunk)
+		if isLineEnd {
+			return length, nil
+		}
+	}
+}
+
+// ApplyColumnRules blanks out the IgnoredColumns of an untrimmed line and checks the ColumnComments indicators, ex: fixed format COBOL
+// Returns the remaining line and whether it is a comment because of an indicator in its column
+func ApplyColumnRules(line string, languageInfo LanguageInfo) (string, bool) {
+	if len(languageInfo.ColumnComments) == 0 && len(languageInfo.IgnoredColumns) == 0 {
+		return line, false
+	}
+	lineBytes, isComment := applyColumnRules([]byte(strings.TrimRight(line, "\r\n")), &languageInfo)
+	return string(lineBytes), isComment
+}
+
+// applyColumnRules is ApplyColumnRules for a line without its line ending, the ignored columns are blanked out in place
+func applyColumnRules(line []byte, languageInfo *LanguageInfo) ([]byte, bool) {
+	if len(languageInfo.ColumnComments) == 0 && len(languageInfo.IgnoredColumns) == 0 {
+		return line, false
+	}
+	// columns are counted in characters, not bytes. A blanked out character becomes a single space, so the line can only shrink
+	length := 0
+	for i, column := 0, 1; i < len(line); column++ {
+		_, size := utf8.DecodeRune(line[i:])
+		if isIgnoredColumn(column, languageInfo) {
+			line[length] = ' '
+			length++
 		} else {
-			// end of a mult-line comment is within the same line, therefore lines of code could be after this
-			splitLine := splitLineByFirstMultiLineComment(line, languageInfo)[1]
-			return AnalyzeLine(splitLine, languageInfo, false)
+			length += copy(line[length:], line[i:i+size])
+		}
+		i += size
+	}
+	line = line[:length]
+
+	for _, columnComment := range languageInfo.ColumnComments {
+		offset := indexOfColumn(line, columnComment.Column)
+		if offset == -1 {
+			continue
+		}
+		for _, indicator := range columnComment.Indicators {
+			if strings.HasPrefix(viewAsString(line[offset:]), indicator) {
+				return line, true
+			}
+		}
+	}
+	return line, false
+}
+
+func isIgnoredColumn(column int, languageInfo *LanguageInfo) bool {
+	for _, ignoredColumns := range languageInfo.IgnoredColumns {
+		if len(ignoredColumns) == 2 && column >= ignoredColumns[0] && column <= ignoredColumns[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the byte index of the character in the column, counted from 1, or -1 if the line is shorter
+func indexOfColumn(line []byte, column int) int {
+	if column < 1 {
+		return -1
+	}
+	for i, currentColumn := 0, 1; i < len(line); currentColumn++ {
+		if currentColumn == column {
+			return i
+		}
+		_, size := utf8.DecodeRune(line[i:])
+		i += size
+	}
+	return -1
+}
+
+// embeddedRegion is an open block of another language within a file, ex: <script lang="ts"> in a .vue file
+type embeddedRegion struct {
+	tag          string
+	openingTag   string // ex: <script, kept so looking for the end of the block does not build it again for every line
+	closingTag   string // ex: </script
+	languageName string
+	languageInfo LanguageInfo
+	syntax       lexerSyntax
+	state        LineState
+	depth        int // number of open tags, only tags outside of rawTextTags may be nested
+}
+
+// tags whose content is not markup, these end at the first closing tag as in the HTML spec
+var rawTextTags = map[string]bool{"script": true, "style": true}
+
+// the attribute naming the language of a block, ex: lang="scss" or type="text/typescript"
+var embeddedLanguageAttributeRegex = regexp.MustCompile(`\b(?:lang|type)\s*=\s*["']?([^"'\s>]+)`)
+
+// AnalyzeEmbeddedLine analyzes a line of a file whose language embeds other languages, ex: <script> and <style> blocks in a .vue file
+// Lines within a block are analyzed with the language of the block, all other lines including the opening and closing tags with the host language
+// Returns the result, the name of the language the line was counted as, the updated host state and the open block, which is nil outside of a block
+func AnalyzeEmbeddedLine(line string, hostName string, hostInfo LanguageInfo, hostState LineState, region *embeddedRegion) (AnalyzeLineResult, string, LineState, *embeddedRegion) {
+	var lineResult AnalyzeLineResult
+	if region != nil {
+		closingIndex := findEmbeddedRegionEnd(line, region)
+		if closingIndex == -1 {
+			lineResult, region.state = analyzeLine(line, &region.languageInfo, &region.syntax, region.state)
+			return lineResult, region.languageName, hostState, region
+		}
+		// code before the closing tag still belongs to the block, ex: foo()</script>
+		beforeClosingTag := strings.TrimSpace(line[:closingIndex])
+		if beforeClosingTag != "" {
+			lineResult, _ = analyzeLine(beforeClosingTag, &region.languageInfo, &region.syntax, region.state)
+			return lineResult, region.languageName, hostState, nil
+		}
+		lineResult, hostState = AnalyzeLine(line, hostInfo, hostState)
+		return lineResult, hostName, hostState, nil
+	}
+
+	// tags within a comment of the host do not open a block
+	if !hostState.InBlockComment() {
+		region = findEmbeddedRegionStart(line, hostName, hostInfo)
+	}
+	lineResult, hostState = AnalyzeLine(line, hostInfo, hostState)
+	return lineResult, hostName, hostState, region
+}
+
+// finds an opening tag of an embedded language at the start of the line
+// Returns nil if there is no such tag or the block is closed on the same line, ex: <script src="app.js"></script>
+func findEmbeddedRegionStart(line string, hostName string, hostInfo LanguageInfo) *embeddedRegion {
+	tag, attributes, tagLength, found := parseOpeningTag(line)
+	if !found {
+		return nil
+	}
+	tag = strings.ToLower(tag)
+	languageName, ok := hostInfo.EmbeddedLanguages[tag]
+	if !ok {
+		return nil
+	}
+	// the tag is kept in the region, but the line it was sliced from may be reused for the next line
+	tag = strings.Clone(tag)
+	if strings.HasSuffix(attributes, "/") || indexFold(line[tagLength:], "</"+tag) != -1 {
+		return nil
+	}
+	// a lang or type attribute picks a more specific language, ex: <style lang="scss">
+	if attributeMatch := embeddedLanguageAttributeRegex.FindStringSubmatch(attributes); attributeMatch != nil {
+		if attributeLanguageName, ok := hostInfo.EmbeddedLanguages[tag+":"+strings.ToLower(attributeMatch[1])]; ok {
+			languageName = attributeLanguageName
+		}
+	}
+	// fall back to the host language when the embedded language is not configured
+	languageInfo, ok := Languages[languageName]
+	if !ok {
+		languageName = hostName
+		languageInfo = hostInfo
+	}
+	return &embeddedRegion{tag: tag, openingTag: "<" + tag, closingTag: "</" + tag, languageName: languageName, languageInfo: languageInfo, syntax: newLexerSyntax(&languageInfo), depth: 1}
+}
+
+// parses an opening tag at the start of a line, ex: <script setup lang="ts">
+// Returns the tag name, the attributes, the length of the whole tag and whether the line starts with an opening tag
+func parseOpeningTag(line string) (string, string, int, bool) {
+	if len(line) < 3 || line[0] != '<' || !isLetter(line[1]) {
+		return "", "", 0, false
+	}
+	nameEnd := 2
+	for nameEnd < len(line) && isTagNameCharacter(line, nameEnd) {
+		nameEnd++
+	}
+	tagEnd := strings.IndexByte(line[nameEnd:], '>')
+	if tagEnd == -1 {
+		return "", "", 0, false
+	}
+	tagEnd += nameEnd
+	// the name must be followed by white space before any attributes, ex: not <script:x>
+	attributes := line[nameEnd:tagEnd]
+	if attributes != "" && !unicode.IsSpace(rune(attributes[0])) {
+		return "", "", 0, false
+	}
+	return line[1:nameEnd], attributes, tagEnd + 1, true
+}
+
+func isLetter(character byte) bool {
+	return (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+}
+
+// finds the closing tag of the open block on the line, nested tags of the same name are skipped
+// Returns the index of the closing tag or -1 if the block continues past the line
+func findEmbeddedRegionEnd(line string, region *embeddedRegion) int {
+	if rawTextTags[region.tag] {
+		return indexFold(line, region.closingTag)
+	}
+	for i := 0; i < len(line); i++ {
+		if hasPrefixFold(line[i:], region.closingTag) {
+			region.depth--
+			if region.depth == 0 {
+				return i
+			}
+		} else if hasPrefixFold(line[i:], region.openingTag) && !isTagNameCharacter(line, i+len(region.openingTag)) {
+			region.depth++
 		}
+	}
+	return -1
+}
+
+// checks if the byte at the index continues a tag name, ex: the s of <templates
+func isTagNameCharacter(line string, index int) bool {
+	if index >= len(line) {
+		return false
+	}
+	c := line[index]
+	return c == '-' || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
 
-		// if not, then it could be code, need to recurse in
+// case insensitive strings.HasPrefix for ASCII prefixes
+func hasPrefixFold(s string, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
 
-	} else if isBlankLine(line) {
-		return BlankLine, false
-	} else if hasSingleLineComment(line, languageInfo) {
-		return Comment, false
+// case insensitive strings.Index for ASCII substrings
+func indexFold(s string, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if hasPrefixFold(s[i:], substr) {
+			return i
+		}
 	}
-	// it must be code
-	return Code, false
+	return -1
+}
 
+// adds an analyzed line to the totals, mixed lines are counted according to CountMixedLinesAs
+// LanguageToCodeLineCount must not be nil
+func (result *FileScanResults) countLine(lineResult AnalyzeLineResult, languageName string) {
+	if lineResult == Code {
+		result.CodeLineCount++
+		result.LanguageToCodeLineCount[languageName]++
+	} else if lineResult == BlankLine {
+		result.BlankLineCount++
+	} else if lineResult == Comment {
+		result.CommentsLineCount++
+	} else if lineResult == DataLine {
+		result.DataLineCount++
+	} else if lineResult == Mixed {
+		result.MixedLineCount++
+		if CountMixedLinesAs != MixedAsComment {
+			result.CodeLineCount++
+			result.LanguageToCodeLineCount[languageName]++
+		}
+		if CountMixedLinesAs != MixedAsCode {
+			result.CommentsLineCount++
+		}
+	}
 }
 
 func ScanFile(filePath string) FileScanResults {
@@ -64,67 +811,104 @@ func ScanFile(filePath string) FileScanResults {
 		CommentsLineCount: 0,
 		TotalLines:        0,
 	}
+	return scanFile(result)
+}
 
-	commentsLineCount := 0
-	codeLineCount := 0
-	blankLineCount := 0
-	totalLines := 0
-
+// scans the file of the result, which may already hold what was found out while walking the directory, ex: the RealPath
+func scanFile(result FileScanResults) FileScanResults {
+	filePath := result.FilePath
 	f, err := os.Open(filePath)
 	if err != nil {
 		logger.Error("File ", filePath, " failed to scan. Counting as 0")
 		logger.Error(err)
 		logger.Error(logger.GetStackTrace())
+		result.SkipReason = "unreadable: " + err.Error()
 		return result
 	}
 	defer f.Close()
 
 	// Get metadata about file
-	fileName := filepath.Base(f.Name())
-	suffix := ParseFileSuffix(fileName)
-	var languageInfo LanguageInfo
-	var langName string
+	langName, languageInfo, detectionRule, foundLanguageInfo := DetectLanguage(filePath)
+	// If not supported return 0s, TODO should probably throw an error or report on it
+	if !foundLanguageInfo {
+		logger.Debug("Skipping file: ", filePath, ". Neither the suffix, file name, shebang nor modeline are supported in config.")
+		result.SkipReason = "unsupported file type"
+		return result
+	}
+	logger.Debug("Detected ", langName, " for ", filePath, " by ", detectionRule)
+	// files with an extension shared by several languages are only known to be left out once their content is read
+	if skipReason := Filter.LanguageSkipReason(langName); skipReason != "" {
+		logger.Debug("Skipping file: ", filePath, ". ", skipReason)
+		result.LanguageName = langName
+		result.SkipReason = skipReason
+		return result
+	}
+	return scanContent(f, result, langName, languageInfo, detectionRule)
+}
 
-	if suffix == "" {
-		foundLanguageInfo := false
-		langName, languageInfo, foundLanguageInfo = LookupByFileName(fileName)
-		if !foundLanguageInfo {
-			logger.Debug("Skipping file: ", fileName, " suffix '", suffix, "'. No suffix and file name not supported in config.")
-			return result
-		}
+// scanContent counts the lines of the content of a file whose language is already detected
+func scanContent(content io.Reader, result FileScanResults, langName string, languageInfo LanguageInfo, detectionRule string) FileScanResults {
+	filePath := result.FilePath
+	totalLines := 0
+	nonBlankLineCount := 0
+	nonBlankCharCount := 0
+	generatedReason := ""
 
-	} else {
-		foundLanguageInfo := false
-		langName, languageInfo, foundLanguageInfo = LookupByExtension(suffix)
-		// If not supported return 0s, TODO should probably throw an error or report on it
-		// TODO Dockerfile does not always have an extension, but we will count it
-		if !foundLanguageInfo {
-			logger.Debug("Skipping file: ", fileName, " suffix '", suffix, "' is not supported.")
-			return result
+	fileReader := fileReaderPool.Get().(*bufio.Reader)
+	fileReader.Reset(content)
+	defer func() {
+		fileReader.Reset(nil)
+		fileReaderPool.Put(fileReader)
+	}()
+	reader := fileReader
+	sample, _ := reader.Peek(BinarySniffBytes)
+
+	// Decode the file into UTF-8, a byte order mark is not part of the first line
+	encoding, byteOrderMarkLength := DetectEncoding(sample)
+	if FileEncoding != EncodingAuto {
+		encoding = FileEncoding
+		byteOrderMarkLength = 0
+		if bytes.HasPrefix(sample, byteOrderMark(encoding)) {
+			byteOrderMarkLength = len(byteOrderMark(encoding))
 		}
 	}
+	logger.Debug("Decoding ", filePath, " as ", encoding)
+	result.Encoding = encoding
+	reader.Discard(byteOrderMarkLength)
+	if encoding != EncodingUTF8 && encoding != EncodingUTF8BOM {
+		reader = bufio.NewReaderSize(NewDecodingReader(reader, encoding), BinarySniffBytes)
+	}
+	// UTF-16 is full of NUL bytes and a forced encoding may be anything but UTF-8, so these are sniffed after decoding
+	if encoding == EncodingUTF16LE || encoding == EncodingUTF16BE || FileEncoding != EncodingAuto {
+		sample, _ = reader.Peek(BinarySniffBytes)
+	}
+
+	// Sniff the start of the file so binaries with a supported suffix are not counted as code
+	if reason, isBinary := DetectBinary(sample); isBinary {
+		logger.Debug("Skipping file: ", filePath, ". Detected ", reason)
+		result.LanguageName = langName
+		result.DetectionRule = detectionRule
+		result.SkipReason = reason
+		return result
+	}
+
+	// notebooks are JSON documents, only the source of their cells is counted
+	if langName == NotebookLanguageName {
+		return scanNotebook(reader, result, langName, detectionRule)
+	}
 
 	// Scan file
-	reader := bufio.NewReader(f)
-	isInBlockComment := false
+	state := LineState{}
+	syntax := newLexerSyntax(&languageInfo)
+	// blocks of other languages, ex: <script> in HTML, are only tracked when the language embeds any
+	isEmbedding := len(languageInfo.EmbeddedLanguages) > 0
+	var region *embeddedRegion
+	result.LanguageToCodeLineCount = map[string]int{}
+	lineReader := NewLineReader(reader)
 	debugLineNum := 1
 	for {
 
-		line, err := reader.ReadString('\n')
-		line = strings.TrimSpace(line)
-
-		lineResult, blockCommentContinuesToNexLine := AnalyzeLine(line, languageInfo, isInBlockComment)
-		if lineResult == Code {
-			isInBlockComment = false
-			codeLineCount++
-		} else if lineResult == BlankLine {
-			isInBlockComment = false
-			blankLineCount++
-		} else if lineResult == Comment {
-			isInBlockComment = blockCommentContinuesToNexLine
-			commentsLineCount++
-		}
-
+		lineBytes, isLineEnd, err := lineReader.ReadLineChunk(nil)
 		if err != nil {
 			// reached end of file
 			if err == io.EOF {
@@ -132,106 +916,641 @@ func ScanFile(filePath string) FileScanResults {
 			}
 			logger.LogStackTraceAndExit(err)
 		}
+		totalLines++
+		// fixed format languages give meaning to columns, so they are applied before trimming
+		lineBytes, isColumnComment := applyColumnRules(lineBytes, &languageInfo)
+		// the line is not copied into a string, nothing may keep it past this iteration
+		line := strings.TrimSpace(viewAsString(lineBytes))
+
+		// generated files announce themselves in a comment at the top of the file
+		if generatedReason == "" && debugLineNum <= GeneratedHeaderLines {
+			generatedReason = DetectGeneratedMarker(line)
+		}
+
+		var lineResult AnalyzeLineResult
+		lineLanguageName := langName
+		lineLength := len(line)
+		if !isLineEnd {
+			// lines longer than a chunk are lexed chunk by chunk, so memory use does not depend on the length of the line
+			lineInfo, lineState := &languageInfo, &state
+			if region != nil {
+				lineLanguageName, lineInfo, lineState = region.languageName, &region.languageInfo, &region.state
+			}
+			lineResult, *lineState, lineLength, err = scanLongLine(lineReader, lineBytes, isColumnComment, lineInfo, *lineState)
+			if err != nil {
+				logger.LogStackTraceAndExit(err)
+			}
+		} else if MaxLineLength > 0 && len(lineBytes) > MaxLineLength {
+			lineResult = DataLine
+		} else if isColumnComment {
+			lineResult = Comment
+		} else if isEmbedding {
+			lineResult, lineLanguageName, state, region = AnalyzeEmbeddedLine(line, langName, languageInfo, state, region)
+		} else {
+			lineResult, state = analyzeLine(line, &languageInfo, &syntax, state)
+		}
+		if lineResult != BlankLine {
+			nonBlankLineCount++
+			nonBlankCharCount += lineLength
+		}
+		result.countLine(lineResult, lineLanguageName)
 		debugLineNum++
 	}
 
+	// minified files squeeze a lot of code into a few very long lines
+	if generatedReason == "" && nonBlankCharCount >= MinifiedMinimumSize && nonBlankCharCount/nonBlankLineCount > MinifiedAverageLineLength {
+		generatedReason = "minified: average line length " + strconv.Itoa(nonBlankCharCount/nonBlankLineCount)
+	}
+	if generatedReason != "" {
+		logger.Debug("Detected ", filePath, " as ", generatedReason)
+	}
+
 	// return the totals
+	result.IsGenerated = generatedReason != ""
+	result.GeneratedReason = generatedReason
 	result.TotalLines = totalLines
-	result.CodeLineCount = codeLineCount
-	result.BlankLineCount = blankLineCount
-	result.CommentsLineCount = commentsLineCount
+	result.LineEnding = lineReader.LineEnding()
 	result.LanguageName = langName
+	result.DetectionRule = detectionRule
 	result.FilePath = filePath
 	return result
 
 }
 
+// size of the buffer files are read through, a large buffer keeps the number of reads per file low
+const ReadBufferSize = 64 * 1024
+
+// buffered readers are reused between files, so every file does not allocate a new buffer
+var fileReaderPool = sync.Pool{
+	New: func() any {
+		return bufio.NewReaderSize(nil, ReadBufferSize)
+	},
+}
+
+// number of bytes at the start of a file that are sniffed for binary content
+const BinarySniffBytes = 8192
+
+// share of bytes in the sniffed sample that may be invalid UTF-8 before the file is treated as binary
+const BinaryInvalidUTF8Ratio = 0.3
+
+// magic numbers of common binary formats, checked against the start of a file
+var binarySignatures = []struct {
+	name      string
+	signature string
+}{
+	{"PDF", "%PDF-"},
+	{"PNG", "\x89PNG\r\n\x1a\n"},
+	{"GIF", "GIF8"},
+	{"JPEG", "\xff\xd8\xff"},
+	{"ZIP", "PK\x03\x04"},
+	{"gzip", "\x1f\x8b"},
+	{"7-Zip", "7z\xbc\xaf\x27\x1c"},
+	{"RAR", "Rar!\x1a\x07"},
+	{"ELF", "\x7fELF"},
+	{"Mach-O", "\xcf\xfa\xed\xfe"},
+	{"Mach-O", "\xce\xfa\xed\xfe"},
+	{"Java class", "\xca\xfe\xba\xbe"},
+	{"WebAssembly", "\x00asm"},
+	{"SQLite", "SQLite format 3\x00"},
+}
+
+// DetectBinary reports whether a sample from the start of a file looks like binary content.
+// Checks magic numbers first, then NUL bytes, then the share of invalid UTF-8.
+// Returns the reason, ex: "binary: PDF signature"
+func DetectBinary(sample []byte) (string, bool) {
+	for _, binarySignature := range binarySignatures {
+		if bytes.HasPrefix(sample, []byte(binarySignature.signature)) {
+			return "binary: " + binarySignature.name + " signature", true
+		}
+	}
+	if bytes.IndexByte(sample, 0) != -1 {
+		return "binary: NUL bytes", true
+	}
+
+	invalidBytes := 0
+	checkedBytes := 0
+	for i := 0; i < len(sample); {
+		// a rune cut off by the end of the sample is not invalid
+		if !utf8.FullRune(sample[i:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(sample[i:])
+		if r == utf8.RuneError && size == 1 {
+			invalidBytes++
+		}
+		checkedBytes += size
+		i += size
+	}
+	if checkedBytes > 0 && float64(invalidBytes)/float64(checkedBytes) > BinaryInvalidUTF8Ratio {
+		return fmt.Sprintf("binary: %d%% invalid UTF-8", invalidBytes*100/checkedBytes), true
+	}
+	return "", false
+}
+
+// number of lines at the start of a file searched for a generated code marker
+const GeneratedHeaderLines = 20
+
+// average length of the non-blank lines in a file above which the file is treated as minified
+const MinifiedAverageLineLength = 150
+
+// number of characters on the non-blank lines of a file below which it is never treated as minified, a small file with a long line is not a bundle
+const MinifiedMinimumSize = 1024
+
+// markers that code generators write into the header of the files they create
+var generatedMarkers = []struct {
+	name  string
+	regex *regexp.Regexp
+}{
+	{"Code generated ... DO NOT EDIT", regexp.MustCompile(`Code generated .*DO NOT EDIT`)},
+	{"@generated", regexp.MustCompile(`@generated\b`)},
+	{"protocol buffer compiler", regexp.MustCompile(`(?i)generated by the protocol buffer compiler`)},
+	{"auto-generated", regexp.MustCompile(`(?i)\b(file|code) (is|was) (auto-?)?generated\b`)},
+}
+
+// DetectGeneratedMarker checks a line from the header of a file for a marker left by a code generator
+// Returns the reason, ex: "generated: @generated marker", or an empty string if the line has no marker
+func DetectGeneratedMarker(line string) string {
+	for _, generatedMarker := range generatedMarkers {
+		if generatedMarker.regex.MatchString(line) {
+			return "generated: " + generatedMarker.name + " marker"
+		}
+	}
+	return ""
+}
+
+// number of lines at the start and at the end of a file that may hold a shebang or modeline
+const modelineSearchLines = 5
+
+// number of bytes read from each end of a file when searching for a shebang or modeline
+const modelineSearchBytes = 4096
+
+var vimModelineRegex = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?[\s:](?:ft|filetype|syntax)=([\w+#.-]+)`)
+var emacsModelineRegex = regexp.MustCompile(`-\*-(.*?)-\*-`)
+
+// DetectLanguage looks up the language of a file by its suffix, then by its file name, then by its shebang or modeline.
+// Returns the rule that decided the language, ex: "extension .go" or "shebang python3"
+func DetectLanguage(filePath string) (string, LanguageInfo, string, bool) {
+	fileName := filepath.Base(filePath)
+	suffix := ParseFileSuffix(fileName)
+	if suffix != "" {
+		return LookupByExtensionAndContent(suffix, filePath)
+	}
+	if langName, languageInfo, found := LookupByFileName(fileName); found {
+		return langName, languageInfo, "file name " + fileName, true
+	}
+	// scripts without a suffix, ex: bin/deploy, may declare their language with a shebang or modeline
+	return LookupByFileContent(filePath)
+}
+
+// LookupByFileContent detects the language of a file from its shebang, ex: #!/usr/bin/env python3,
+// or from a vim or emacs modeline, ex: vim: ft=ruby or -*- mode: perl -*-
+func LookupByFileContent(filePath string) (string, LanguageInfo, string, bool) {
+	headLines, tailLines, err := readHeadAndTailLines(filePath)
+	if err != nil {
+		logger.Debug("Unable to read ", filePath, " to detect its language: ", err)
+		return "", LanguageInfo{}, "", false
+	}
+
+	if len(headLines) > 0 {
+		if interpreter, found := parseShebang(headLines[0]); found {
+			if langName, languageInfo, found := LookupByInterpreter(interpreter); found {
+				return langName, languageInfo, "shebang " + interpreter, true
+			}
+		}
+	}
+
+	// emacs only reads the first line, or the second after a shebang, vim reads both ends of the file
+	for index, line := range headLines {
+		if index < 2 {
+			if mode, found := parseEmacsModeline(line); found {
+				if langName, languageInfo, found := LookupByModeline(mode); found {
+					return langName, languageInfo, "emacs modeline " + mode, true
+				}
+			}
+		}
+	}
+	for _, line := range append(headLines, tailLines...) {
+		if fileType, found := parseVimModeline(line); found {
+			if langName, languageInfo, found := LookupByModeline(fileType); found {
+				return langName, languageInfo, "vim modeline " + fileType, true
+			}
+		}
+	}
+	return "", LanguageInfo{}, "", false
+}
+
+// reads the first and last lines of a file without reading the whole file
+func readHeadAndTailLines(filePath string) ([]string, []string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, modelineSearchBytes)
+	headLength, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	headLines := strings.Split(string(head[:headLength]), "\n")
+	if len(headLines) > modelineSearchLines {
+		headLines = headLines[:modelineSearchLines]
+	}
+
+	// the whole file fits in the head, so the tail is already covered
+	if headLength < modelineSearchBytes {
+		return headLines, []string{}, nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	tail := make([]byte, modelineSearchBytes)
+	tailLength, err := f.ReadAt(tail, max(info.Size()-modelineSearchBytes, int64(headLength)))
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	tailLines := strings.Split(strings.TrimRight(string(tail[:tailLength]), "\n"), "\n")
+	if len(tailLines) > modelineSearchLines {
+		tailLines = tailLines[len(tailLines)-modelineSearchLines:]
+	}
+	return headLines, tailLines, nil
+}
+
+// returns the interpreter named by a shebang line, ex: "#!/usr/bin/env -S python3 -u" is python3
+func parseShebang(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "#!") {
+		return "", false
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return "", false
+	}
+	interpreter := filepath.Base(fields[0])
+	// env runs the interpreter given as its first argument that is not an option or variable assignment
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+	return interpreter, interpreter != ""
+}
+
+// returns the file type of a vim modeline, ex: "# vim: set ft=ruby:" is ruby
+func parseVimModeline(line string) (string, bool) {
+	matches := vimModelineRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// returns the mode of an emacs modeline, ex: "-*- mode: perl; coding: utf-8 -*-" and "-*- perl -*-" are both perl
+func parseEmacsModeline(line string) (string, bool) {
+	matches := emacsModelineRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return "", false
+	}
+	content := strings.TrimSpace(matches[1])
+	if !strings.Contains(content, ":") {
+		return content, content != ""
+	}
+	for _, variable := range strings.Split(content, ";") {
+		name, value, found := strings.Cut(variable, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "mode") {
+			value = strings.TrimSpace(value)
+			return value, value != ""
+		}
+	}
+	return "", false
+}
+
 /*
 *
-@singleLineCommentPrefix is something "/" or "//" or "#"
+@singleLineCommentPrefix is something "/" or "//" or "#", every prefix declared for the language is checked
+unless the line starts with one of the LineCommentExceptions, ex: #[ in PHP
+@isWordStart is false when the line continues a word, the WordStartLineComments do not start a comment there, ex: $# in Shell
 */
-func hasSingleLineComment(line string, languageInfo LanguageInfo) bool {
+func hasSingleLineComment(line string, languageInfo *LanguageInfo, isWordStart bool) bool {
+	for _, exception := range languageInfo.LineCommentExceptions {
+		if strings.HasPrefix(line, exception) {
+			return false
+		}
+	}
 	for _, singleLineCommentPrefix := range languageInfo.LineComments {
-		return strings.HasPrefix(line, singleLineCommentPrefix)
+		if strings.HasPrefix(line, singleLineCommentPrefix) && (isWordStart || !slices.Contains(languageInfo.WordStartLineComments, singleLineCommentPrefix)) {
+			return true
+		}
 	}
 	return false
 }
 
-func beginsWithFirstMultiLineComment(line string, languageInfo LanguageInfo) bool {
-	for _, pair := range languageInfo.MultiLineComments {
+// checks whether a token after the given byte starts a word, the start of the line is passed in as 0
+func isWordStart(previous byte) bool {
+	return previous == 0 || previous == ';' || unicode.IsSpace(rune(previous))
+}
+
+// checks every multi-line comment pair and returns the index of the pair whose opening token starts the line
+// the longest opening token wins when several match
+func beginsWithMultiLineComment(line string, languageInfo *LanguageInfo) (int, bool) {
+	matchIndex := -1
+	for index, pair := range languageInfo.MultiLineComments {
 		firstMultiLineCommentToken := pair[0]
-		return strings.HasPrefix(line, firstMultiLineCommentToken)
+		if strings.HasPrefix(line, firstMultiLineCommentToken) && (matchIndex < 0 || len(firstMultiLineCommentToken) > len(languageInfo.MultiLineComments[matchIndex][0])) {
+			matchIndex = index
+		}
 	}
-	return false
+	return matchIndex, matchIndex >= 0
 }
 
-func hasSecondMultiLineComment(line string, languageInfo LanguageInfo) bool {
-	for _, pair := range languageInfo.MultiLineComments {
-		secondMultiLineCommentToken := pair[1]
-		return strings.Contains(line, secondMultiLineCommentToken)
+// returns the index of the closing token of the given multi-line comment pair in the line and the length of that token, or -1 if there is none
+func indexOfSecondMultiLineComment(line string, languageInfo *LanguageInfo, pairIndex int) (int, int) {
+	if pairIndex < 0 || pairIndex >= len(languageInfo.MultiLineComments) {
+		return -1, 0
 	}
-	return false
+	secondMultiLineCommentToken := languageInfo.MultiLineComments[pairIndex][1]
+	return strings.Index(line, secondMultiLineCommentToken), len(secondMultiLineCommentToken)
 }
-func endsWithSecondMultiLineComment(line string, languageInfo LanguageInfo) bool {
-	for _, pair := range languageInfo.MultiLineComments {
-		secondMultiLineCommentToken := pair[1]
-		return strings.HasSuffix(line, secondMultiLineCommentToken)
+
+// returns the index of the opening token of the given multi-line comment pair in the line and the length of that token,
+// or -1 if there is none or the pair does not nest in this language
+func indexOfNestedMultiLineComment(line string, languageInfo *LanguageInfo, pairIndex int) (int, int) {
+	if pairIndex < 0 || pairIndex >= len(languageInfo.MultiLineComments) {
+		return -1, 0
+	}
+	firstMultiLineCommentToken := languageInfo.MultiLineComments[pairIndex][0]
+	for _, nestedCommentToken := range languageInfo.NestedComments {
+		if nestedCommentToken == firstMultiLineCommentToken {
+			return strings.Index(line, firstMultiLineCommentToken), len(firstMultiLineCommentToken)
+		}
+	}
+	return -1, 0
+}
+
+// checks if the line starts with a string literal delimiter, multi-line delimiters such as """ take precedence over "
+// returns the opening delimiter, the closing delimiter and whether the literal may span multiple lines
+// the returned opening delimiter includes any string prefix, ex: r""" or f'
+func beginsWithStringLiteral(line string, languageInfo *LanguageInfo, allowPrefix bool) (string, string, bool, bool) {
+	prefixLengths := []int{0}
+	if allowPrefix {
+		if prefixLength := lengthOfStringPrefix(line, languageInfo); prefixLength > 0 {
+			prefixLengths = []int{prefixLength, 0}
+		}
+	}
+	for _, prefixLength := range prefixLengths {
+		for _, pair := range languageInfo.MultiLineStringDelimiters {
+			if strings.HasPrefix(line[prefixLength:], pair[0]) {
+				return line[:prefixLength+len(pair[0])], pair[1], true, true
+			}
+		}
+		for _, pair := range languageInfo.StringDelimiters {
+			if strings.HasPrefix(line[prefixLength:], pair[0]) {
+				return line[:prefixLength+len(pair[0])], pair[1], false, true
+			}
+		}
+	}
+	return "", "", false, false
+}
+
+// returns the regular expression delimiter that starts the line, or "" if there is none
+func beginsWithRegex(line string, languageInfo *LanguageInfo) string {
+	for _, regexDelimiter := range languageInfo.RegexDelimiters {
+		if regexDelimiter != "" && strings.HasPrefix(line, regexDelimiter) {
+			return regexDelimiter
+		}
+	}
+	return ""
+}
+
+// returns the length of the label delimiter that starts the line, or 0 if the line does not start with a label
+// a char literal closes after a single character, ex: 'a' is a literal but 'a and 'static are labels or lifetimes
+func beginsWithLabel(line string, languageInfo *LanguageInfo) int {
+	for _, labelDelimiter := range languageInfo.LabelDelimiters {
+		if labelDelimiter == "" || !strings.HasPrefix(line, labelDelimiter) {
+			continue
+		}
+		afterDelimiter := line[len(labelDelimiter):]
+		if afterDelimiter != "" && isWordCharacter(afterDelimiter[0]) && !strings.HasPrefix(afterDelimiter[1:], labelDelimiter) {
+			return len(labelDelimiter)
+		}
+	}
+	return 0
+}
+
+// returns the length of the longest string prefix at the start of the line, prefixes are case insensitive, ex: Rb
+func lengthOfStringPrefix(line string, languageInfo *LanguageInfo) int {
+	length := 0
+	for _, prefix := range languageInfo.StringPrefixes {
+		if len(prefix) > length && len(line) >= len(prefix) && strings.EqualFold(line[:len(prefix)], prefix) {
+			length = len(prefix)
+		}
+	}
+	return length
+}
+
+// checks if the opening delimiter, including any string prefix, opens a docstring when used as a statement
+func isDocStringDelimiter(opener string, languageInfo *LanguageInfo) bool {
+	for _, docStringDelimiter := range languageInfo.DocStringDelimiters {
+		if strings.HasSuffix(opener, docStringDelimiter) {
+			return true
+		}
 	}
 	return false
 }
-func splitLineByFirstMultiLineComment(line string, languageInfo LanguageInfo) []string {
+
+// byteSet is a set of bytes, used to check a byte against many tokens at once
+type byteSet [4]uint64
+
+func (set *byteSet) add(b byte) {
+	set[b/64] |= 1 << (b % 64)
+}
+
+func (set *byteSet) contains(b byte) bool {
+	return set[b/64]&(1<<(b%64)) != 0
+}
+
+// returns the index of the first byte of the line at or after the start that is in the set, or the length of the line
+// the check of contains is repeated here, this loop runs over most bytes of a file and must not call a method per byte
+func (set *byteSet) indexIn(line string, start int) int {
+	for i := start; i < len(line); i++ {
+		if set[line[i]>>6]&(1<<(line[i]&63)) != 0 {
+			return i
+		}
+	}
+	return len(line)
+}
+
+// returns the bytes that can start a comment, string or regular expression literal of the language, including both cases of string prefixes
+func tokenStartBytes(languageInfo *LanguageInfo) byteSet {
+	set := byteSet{}
+	addToken := func(token string) {
+		if token == "" {
+			// an empty token matches everywhere
+			set = byteSet{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+			return
+		}
+		set.add(token[0])
+	}
+	for _, token := range languageInfo.LineComments {
+		addToken(token)
+	}
 	for _, pair := range languageInfo.MultiLineComments {
-		secondMultiLineCommentToken := pair[1]
-		return strings.SplitN(line, secondMultiLineCommentToken, 2)
+		addToken(pair[0])
+	}
+	for _, pair := range languageInfo.StringDelimiters {
+		addToken(pair[0])
+	}
+	for _, pair := range languageInfo.MultiLineStringDelimiters {
+		addToken(pair[0])
+	}
+	for _, prefix := range languageInfo.StringPrefixes {
+		addToken(prefix)
+		if prefix != "" && isLetter(prefix[0]) {
+			set.add(prefix[0] ^ 0x20) // the other case of an ASCII letter
+		}
+	}
+	for _, regexDelimiter := range languageInfo.RegexDelimiters {
+		addToken(regexDelimiter)
+	}
+	return set
+}
+
+func isWordCharacter(character byte) bool {
+	return character == '_' || (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || (character >= '0' && character <= '9')
+}
+
+// returns the index just past the closing delimiter of a string literal, skipping escaped characters, or -1 if the literal is not closed before the limit
+// also returns how far the line was scanned, a literal that is not closed in one chunk of a line continues from there in the next
+// @line is the remainder of the line after the opening delimiter
+func indexOfStringEnd(line string, closingDelimiter string, languageInfo *LanguageInfo, limit int) (int, int) {
+	i := 0
+	for i < len(line) && i < limit {
+		// bytes which can neither escape a character nor close the literal are skipped in bulk
+		i += indexOfStringStop(line[i:min(len(line), limit)], closingDelimiter, languageInfo)
+		if i >= len(line) || i >= limit {
+			break
+		}
+		if escapeCharacter := beginsWithEscapeCharacter(line[i:], languageInfo); escapeCharacter != "" {
+			// skip the escape character and the character it escapes
+			i += len(escapeCharacter) + 1
+			continue
+		}
+		if strings.HasPrefix(line[i:], closingDelimiter) {
+			return i + len(closingDelimiter), i
+		}
+		i++
+	}
+	return -1, min(i, len(line))
+}
+
+// returns the index of the first byte in the line which may start an escape character or the closing delimiter, or the length of the line
+func indexOfStringStop(line string, closingDelimiter string, languageInfo *LanguageInfo) int {
+	if closingDelimiter == "" {
+		return 0
+	}
+	stop := strings.IndexByte(line, closingDelimiter[0])
+	if stop < 0 {
+		stop = len(line)
 	}
-	return []string{}
+	for _, escapeCharacter := range languageInfo.EscapeCharacters {
+		if escapeCharacter == "" {
+			return 0
+		}
+		if index := strings.IndexByte(line[:stop], escapeCharacter[0]); index >= 0 {
+			stop = index
+		}
+	}
+	return stop
 }
 
-func isBlankLine(line string) bool {
-	return len(line) == 0
+// returns the length of the longest token of the language, a token starting closer than that to the end of a chunk may be cut off
+func maxTokenLength(languageInfo *LanguageInfo) int {
+	length := 1
+	for _, token := range languageInfo.LineComments {
+		length = max(length, len(token))
+	}
+	for _, token := range languageInfo.LineCommentExceptions {
+		length = max(length, len(token))
+	}
+	for _, pair := range languageInfo.MultiLineComments {
+		length = max(length, len(pair[0]), len(pair[1]))
+	}
+	prefixLength := 0
+	for _, prefix := range languageInfo.StringPrefixes {
+		prefixLength = max(prefixLength, len(prefix))
+	}
+	for _, pair := range languageInfo.StringDelimiters {
+		length = max(length, prefixLength+len(pair[0]), len(pair[1]))
+	}
+	for _, pair := range languageInfo.MultiLineStringDelimiters {
+		length = max(length, prefixLength+len(pair[0]), len(pair[1]))
+	}
+	for _, labelDelimiter := range languageInfo.LabelDelimiters {
+		// a label is told apart from a literal by the two characters after its delimiter
+		length = max(length, len(labelDelimiter)+2)
+	}
+	for _, regexDelimiter := range languageInfo.RegexDelimiters {
+		length = max(length, len(regexDelimiter))
+	}
+	for _, escapeCharacter := range languageInfo.EscapeCharacters {
+		// the escaped character is skipped along with the escape character
+		length = max(length, len(escapeCharacter)+1)
+	}
+	return length
 }
 
-func loadIgnorePatterns(patterns []string) []*regexp.Regexp {
-	var regexps []*regexp.Regexp
-	for _, pattern := range patterns {
-		// got *.json
-		// "^(.*\.json)$"
-		newPattern := "^" + strings.ReplaceAll(strings.ReplaceAll(pattern, ".", "\\."), "*", ".*") + "$"
-		regexps = append(regexps, regexp.MustCompile(newPattern))
-		logger.Debug("Adding pattern " + newPattern)
+func beginsWithEscapeCharacter(line string, languageInfo *LanguageInfo) string {
+	for _, escapeCharacter := range languageInfo.EscapeCharacters {
+		if strings.HasPrefix(line, escapeCharacter) {
+			return escapeCharacter
+		}
 	}
-	return regexps
+	return ""
 }
 
 // ReadIgnoreFile reads a file specified by the given path and returns a slice of strings
-// containing non-empty, trimmed lines from the file. It logs the file path being read and
+// containing the patterns in the file. Blank lines and comments starting with # are skipped,
+// trailing spaces are removed unless escaped with a backslash. It logs the file path being read and
 // exits the program if an error occurs while reading the file.
 //
 // Parameters:
 //   - path: The file path to read.
 //
 // Returns:
-//   - A slice of strings containing the non-empty, trimmed lines from the file.
+//   - A slice of strings containing the patterns from the file.
 func ReadIgnoreFile(path string) []string {
 	logger.Debug("Reading ignore file ", path)
-	data, err := os.ReadFile(path)
+	lines, err := readIgnoreFileLines(path)
 	if err != nil {
 		logger.LogStackTraceAndExit(err)
 	}
 
-	// Split the file content by new lines and trim spaces
-	lines := strings.Split(string(data), "\n")
 	var ignoreList []string
 	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" { // Ignore empty lines
-			ignoreList = append(ignoreList, trimmed)
+		pattern := trimUnescapedTrailingSpaces(line)
+		if pattern != "" && !strings.HasPrefix(pattern, "#") { // Ignore empty lines and comments
+			ignoreList = append(ignoreList, pattern)
 		}
 	}
 
 	return ignoreList
 }
 
+// reads all lines of an ignore file, including blank lines and comments so the line numbers can be reported
+func readIgnoreFileLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Split the file content by new lines, leading spaces are part of the pattern in the gitignore format
+	return strings.Split(string(data), "\n"), nil
+}
+
 func ParseFileSuffix(fileName string) string {
 	splitArr := strings.Split(fileName, ".")
 	if len(splitArr) > 1 {
@@ -243,9 +1562,92 @@ func ParseFileSuffix(fileName string) string {
 	return ""
 }
 
-func WalkDirectory(targetPath string, ignorePatterns []string) []string {
-	patterns := loadIgnorePatterns(ignorePatterns)
+// WalkDirectory returns the absolute paths of all supported files in the directory
+func WalkDirectory(targetPath string, ignoreFiles []IgnoreFile) []string {
+	return walkDirectories([]string{targetPath}, ignoreFiles)
+}
+
+// returns the absolute paths of the supported files in the directories in the order they are found
+// A file reachable from several directories is only returned the first time, files left out by the Filter are not returned
+func walkDirectories(targetPaths []string, ignoreFiles []IgnoreFile) []string {
+	files := make(chan FileScanResults)
+	go walkDirectoriesToChannel(targetPaths, ignoreFiles, files)
+
+	var filePaths []string
+	for file := range files {
+		if file.SkipReason == "" {
+			filePaths = append(filePaths, file.FilePath)
+		}
+	}
+	return filePaths
+}
+
+// FollowSymlinks walks the directories behind symbolic links, set by the --follow-symlinks CLI argument
+// Symbolic links to files are scanned either way
+var FollowSymlinks = false
 
+// identifies a file or directory independently of the path it is reached through, ex: the device and inode on unix
+type fileID struct {
+	device uint64
+	inode  uint64
+}
+
+// directoryWalker holds what the walks of all target directories share
+type directoryWalker struct {
+	ignoreFiles        []IgnoreFile
+	files              chan<- FileScanResults // files to scan, files left out by the Filter are sent with their SkipReason
+	dedupFiles         bool                   // a file may be reached more than once, ex: the target directories nest or symbolic links are followed
+	foundFileIDs       map[fileID]bool        // files found so far when dedupFiles is set, so a file reached through several paths is sent once
+	foundFilePaths     map[string]bool        // same as foundFileIDs by real path, for platforms where files cannot be identified
+	walkedDirectoryIDs map[fileID]bool        // directories walked when following symbolic links, so links to a parent directory do not loop
+}
+
+// walks the directories and sends the files found, the files left out by the Filter are sent as skipped results
+// Closes the channel once the walk is done
+func walkDirectoriesToChannel(targetPaths []string, ignoreFiles []IgnoreFile, files chan<- FileScanResults) {
+	defer close(files)
+	walker := &directoryWalker{
+		ignoreFiles:        ignoreFiles,
+		files:              files,
+		dedupFiles:         FollowSymlinks || targetPathsOverlap(targetPaths),
+		foundFileIDs:       map[fileID]bool{},
+		foundFilePaths:     map[string]bool{},
+		walkedDirectoryIDs: map[fileID]bool{},
+	}
+	for _, targetPath := range targetPaths {
+		walker.walk(targetPath)
+	}
+}
+
+// returns true if a target path is the same as or inside of another one, ex: "." and "./src"
+// Symbolic links are resolved first, so a target given once through a link and once by its real path overlaps as well
+func targetPathsOverlap(targetPaths []string) bool {
+	realPaths := make([]string, 0, len(targetPaths))
+	for _, targetPath := range targetPaths {
+		realPath, err := filepath.Abs(targetPath)
+		if err != nil {
+			continue
+		}
+		if resolvedPath, err := filepath.EvalSymlinks(realPath); err == nil {
+			realPath = resolvedPath
+		}
+		for _, otherPath := range realPaths {
+			if isWithinDirectory(realPath, otherPath) || isWithinDirectory(otherPath, realPath) {
+				return true
+			}
+		}
+		realPaths = append(realPaths, realPath)
+	}
+	return false
+}
+
+// returns true if the path is the directory itself or inside of it
+func isWithinDirectory(path string, directory string) bool {
+	relPath, err := filepath.Rel(directory, path)
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+func (walker *directoryWalker) walk(targetPath string) {
 	// Store the current working directory
 	originalDir, err := os.Getwd()
 	if err != nil {
@@ -253,8 +1655,15 @@ func WalkDirectory(targetPath string, ignorePatterns []string) []string {
 	}
 
 	logger.Debug("Target directory is ", targetPath)
-	var filePaths []string
-	err = filepath.WalkDir(targetPath, func(path string, info os.DirEntry, err error) error {
+	absTargetPath, err := filepath.Abs(targetPath)
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+	scope := newIgnoreScope(absTargetPath, walker.ignoreFiles)
+	// number of symbolic links to directories the walk is currently inside of
+	linkDepth := 0
+	var visit func(path string, info os.DirEntry, err error) error
+	visit = func(path string, info os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -264,36 +1673,51 @@ func WalkDirectory(targetPath string, ignorePatterns []string) []string {
 			logger.Error("Error getting absolute path:", err)
 			return err
 		}
-		// Check if the file matches any of the ignore patterns
-		for _, pattern := range patterns {
-			if pattern.Match([]byte(path)) {
+		// Check if the file is excluded by the ignore files, files inside an ignored directory cannot be re-included
+		// The target itself is always scanned, it was asked for explicitly
+		scope.enter(absPath)
+		if absPath != absTargetPath {
+			if info.IsDir() && UseVCSIgnoreFiles && info.Name() == ".git" {
+				logger.Debug("Skipping dir - ", path, " - git repository data")
+				return filepath.SkipDir
+			}
+			if ignoreFile, pattern := scope.match(absPath, info.IsDir()); pattern != nil && !pattern.Negated {
 				if info.IsDir() {
-					logger.Debug("Skipping dir - ", path, " - pattern match - ", pattern)
+					logger.Debug("Skipping dir - ", path, " - ignored by ", ignoreFile.Describe(pattern))
 					return filepath.SkipDir
-				} else {
-					logger.Debug("Skipping file - ", path, " - pattern match - ", pattern)
 				}
+				logger.Debug("Skipping file - ", path, " - ignored by ", ignoreFile.Describe(pattern))
 				return nil
 			}
 		}
-		if !info.IsDir() {
-			suffix := ParseFileSuffix(info.Name())
-			var found bool
-			if suffix == "" {
-				_, _, found = LookupByFileName(filepath.Base(info.Name()))
-			} else {
-				_, _, found = LookupByExtension(suffix)
+		isSymlink := info.Type()&os.ModeSymlink != 0
+		if FollowSymlinks && isSymlink {
+			linkInfo, err := os.Stat(path)
+			if err != nil {
+				logger.Debug("Skipping link - ", path, " - ", err)
+				return nil
 			}
-
-			if found {
-				filePaths = append(filePaths, absPath)
-			} else {
-				logger.Debug("Skipping file - ", path, " suffix - ", suffix, " - not supported")
+			if linkInfo.IsDir() {
+				// with a trailing separator the directory the link points to is walked, the paths found stay inside the link
+				logger.Debug("Following link - ", path)
+				linkDepth++
+				err = filepath.WalkDir(path+string(filepath.Separator), visit)
+				linkDepth--
+				return err
+			}
+		}
+		if info.IsDir() {
+			if FollowSymlinks && !walker.enterDirectory(path) {
+				logger.Debug("Skipping dir - ", path, " - already walked through another link")
+				return filepath.SkipDir
 			}
+			scope.addDirectory(absPath)
 			return nil
 		}
-		return err
-	})
+		walker.addFile(path, absPath, info.Name(), linkDepth > 0 || isSymlink)
+		return nil
+	}
+	err = filepath.WalkDir(targetPath, visit)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -303,6 +1727,82 @@ func WalkDirectory(targetPath string, ignorePatterns []string) []string {
 	if err != nil {
 		logger.Debug("Error changing back to the original directory:", err)
 	}
+}
 
-	return filePaths
+// returns false if the directory was already walked, ex: a link to a parent directory
+func (walker *directoryWalker) enterDirectory(path string) bool {
+	id, ok := getFileID(path)
+	if !ok {
+		return true
+	}
+	if walker.walkedDirectoryIDs[id] {
+		return false
+	}
+	walker.walkedDirectoryIDs[id] = true
+	return true
+}
+
+// returns true if the file was found before, otherwise remembers it as found
+func (walker *directoryWalker) isFound(absPath string) bool {
+	if id, ok := getFileID(absPath); ok {
+		if walker.foundFileIDs[id] {
+			return true
+		}
+		walker.foundFileIDs[id] = true
+		return false
+	}
+	realPath, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		realPath = absPath
+	}
+	if walker.foundFilePaths[realPath] {
+		return true
+	}
+	walker.foundFilePaths[realPath] = true
+	return false
+}
+
+// sends the file if its language is supported, isThroughLink is true when the path contains a symbolic link
+func (walker *directoryWalker) addFile(path string, absPath string, fileName string, isThroughLink bool) {
+	suffix := ParseFileSuffix(fileName)
+	// the extension is checked first, so files left out by the filter are not opened to detect their language
+	skipReason := Filter.ExtensionSkipReason(fileName)
+	var languageNames []string
+	if suffix == "" {
+		if langName, _, found := LookupByFileName(filepath.Base(fileName)); found {
+			languageNames = []string{langName}
+		} else if skipReason == "" {
+			if langName, _, _, found := LookupByFileContent(path); found {
+				languageNames = []string{langName}
+			}
+		}
+	} else {
+		languageNames = lookupCandidatesByExtension(suffix)
+	}
+
+	if len(languageNames) == 0 {
+		logger.Debug("Skipping file - ", path, " suffix - ", suffix, " - not supported")
+		return
+	}
+	if walker.dedupFiles && walker.isFound(absPath) {
+		logger.Debug("Skipping file - ", path, " - already found in another directory or through another link")
+		return
+	}
+	file := FileScanResults{FilePath: absPath}
+	if FollowSymlinks && isThroughLink {
+		if realPath, err := filepath.EvalSymlinks(absPath); err == nil && realPath != absPath {
+			file.RealPath = realPath
+		}
+	}
+	if skipReason == "" {
+		skipReason = Filter.candidatesSkipReason(languageNames)
+	}
+	if skipReason != "" {
+		logger.Debug("Skipping file - ", path, " - ", skipReason)
+		if len(languageNames) == 1 {
+			file.LanguageName = languageNames[0]
+		}
+		file.SkipReason = skipReason
+	}
+	walker.files <- file
 }
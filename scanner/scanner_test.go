@@ -1,8 +1,15 @@
 package scanner
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"go-cloc/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,7 +29,7 @@ func Test_scanner_ScanFile_javascript_hard(t *testing.T) {
 	// Assert
 	assert.Equal(t, 16, result.CodeLineCount)
 	assert.Equal(t, 27, result.CommentsLineCount)
-	assert.Equal(t, 8, result.BlankLineCount)
+	assert.Equal(t, 7, result.BlankLineCount)
 }
 
 func Test_scanner_ScanFile_c_hard(t *testing.T) {
@@ -31,7 +38,7 @@ func Test_scanner_ScanFile_c_hard(t *testing.T) {
 	// Assert
 	assert.Equal(t, 780, result.CodeLineCount)
 	assert.Equal(t, 418, result.CommentsLineCount)
-	assert.Equal(t, 135, result.BlankLineCount)
+	assert.Equal(t, 134, result.BlankLineCount)
 
 }
 func Test_scanner_ScanFile_c_evil(t *testing.T) {
@@ -44,6 +51,124 @@ func Test_scanner_ScanFile_c_evil(t *testing.T) {
 
 }
 
+func Test_scanner_ScanFile_c_trailing_block_comment(t *testing.T) {
+	result := ScanFile("test-files/c/trailing-block-comment.c")
+
+	// Assert
+	assert.Equal(t, 6, result.CodeLineCount)
+	assert.Equal(t, 2, result.CommentsLineCount)
+	assert.Equal(t, 1, result.BlankLineCount)
+}
+
+func Test_scanner_ScanFile_mixed_line_policies(t *testing.T) {
+	defer func() { CountMixedLinesAs = MixedAsCode }()
+	tests := []struct {
+		policy   MixedLinePolicy
+		code     int
+		comments int
+	}{
+		{MixedAsCode, 6, 2},
+		{MixedAsComment, 2, 6},
+		{MixedAsBoth, 6, 6},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			CountMixedLinesAs = test.policy
+			result := ScanFile("test-files/c/trailing-block-comment.c")
+
+			// Assert
+			assert.Equal(t, 4, result.MixedLineCount)
+			assert.Equal(t, test.code, result.CodeLineCount)
+			assert.Equal(t, test.comments, result.CommentsLineCount)
+		})
+	}
+}
+
+func Test_scanner_ParseMixedLinePolicy(t *testing.T) {
+	policy, ok := ParseMixedLinePolicy("Both")
+	assert.True(t, ok)
+	assert.Equal(t, MixedAsBoth, policy)
+
+	_, ok = ParseMixedLinePolicy("half")
+	assert.False(t, ok)
+}
+
+func Test_scanner_AnalyzeLine_block_comment_after_code(t *testing.T) {
+	_, languageInfo, _ := LookupByExtension(".c")
+
+	result, state := AnalyzeLine("int x = 1; /* start of a long", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+	assert.True(t, state.InBlockComment())
+
+	result, state = AnalyzeLine("comment line", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.True(t, state.InBlockComment())
+
+	result, state = AnalyzeLine("end of comment */", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.False(t, state.InBlockComment())
+
+	// openers inside strings are still ignored
+	result, state = AnalyzeLine("static const char kDeathTestCaseFilter[] = \"*DeathTest:*DeathTest/*\";", languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+	assert.False(t, state.InBlockComment())
+}
+
+func Test_scanner_ScanFile_python_docstrings(t *testing.T) {
+	result := ScanFile("test-files/python/docstrings.py")
+
+	// Assert
+	assert.Equal(t, 17, result.CodeLineCount)
+	assert.Equal(t, 10, result.CommentsLineCount)
+	assert.Equal(t, 6, result.BlankLineCount)
+}
+
+func Test_scanner_AnalyzeLine_python_docstrings(t *testing.T) {
+	_, languageInfo, _ := LookupByExtension(".py")
+
+	tests := []struct {
+		line     string
+		expected AnalyzeLineResult
+	}{
+		{`"""Docstring."""`, Comment},
+		{`'''Docstring.'''`, Comment},
+		{`r"""Raw docstring."""`, Comment},
+		{`F'''Prefixed docstring.'''`, Comment},
+		{`"""Docstring.""" # comment`, Comment},
+		{`x = """expression"""`, Code},
+		{`"""expression""".strip()`, Code},
+		{`return """expression"""`, Code},
+		{`"single quoted strings are not docstrings"`, Code},
+		{`bar"""not a prefix"""`, Code},
+	}
+	for _, test := range tests {
+		result, state := AnalyzeLine(test.line, languageInfo, LineState{})
+		assert.Equal(t, test.expected, result, test.line)
+		assert.Equal(t, LineState{}, state, test.line)
+	}
+
+	// a triple quoted string inside brackets is part of an expression
+	_, state := AnalyzeLine("call(", languageInfo, LineState{})
+	assert.Equal(t, 1, state.BracketDepth)
+	result, state := AnalyzeLine(`"""argument`, languageInfo, state)
+	assert.Equal(t, Code, result)
+	result, state = AnalyzeLine(`"""`, languageInfo, state)
+	assert.Equal(t, Code, result)
+	_, state = AnalyzeLine(")", languageInfo, state)
+	assert.Equal(t, LineState{}, state)
+
+	// docstrings spanning lines are documentation until they close, blank lines included
+	result, state = AnalyzeLine(`"""Summary`, languageInfo, LineState{})
+	assert.Equal(t, Comment, result)
+	assert.True(t, state.OpenDocString)
+	result, state = AnalyzeLine("", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	result, state = AnalyzeLine(`"""`, languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.Equal(t, LineState{}, state)
+}
+
 func Test_scanner_ScanFile_cpp_hard(t *testing.T) {
 	result := ScanFile("test-files/cpp/hard.cpp")
 
@@ -60,18 +185,328 @@ func Test_scanner_ScanFile_cpp_evil(t *testing.T) {
 	// Assert
 	assert.Equal(t, 7158, result.CodeLineCount)
 	assert.Equal(t, 2743, result.CommentsLineCount)
-	assert.Equal(t, 1548, result.BlankLineCount)
+	assert.Equal(t, 1547, result.BlankLineCount)
+
+}
 
+func Test_scanner_ScanFile_nested_comments(t *testing.T) {
+	tests := []struct {
+		filePath string
+		code     int
+		comments int
+		blank    int
+	}{
+		{"test-files/swift/nested.swift", 5, 8, 2},
+		{"test-files/scala/nested.scala", 6, 8, 2},
+		{"test-files/kotlin/nested.kt", 5, 6, 2},
+		{"test-files/rust/nested.rs", 5, 5, 1},
+		{"test-files/haskell/nested.hs", 5, 5, 1},
+		{"test-files/d/nested.d", 6, 6, 2},
+		{"test-files/ocaml/nested.ml", 3, 4, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filePath, func(t *testing.T) {
+			result := ScanFile(test.filePath)
+
+			// Assert
+			assert.Equal(t, test.code, result.CodeLineCount)
+			assert.Equal(t, test.comments, result.CommentsLineCount)
+			assert.Equal(t, test.blank, result.BlankLineCount)
+		})
+	}
+}
+
+func Test_scanner_AnalyzeLine_nested_comment_depth(t *testing.T) {
+	_, languageInfo, _ := LookupByExtension(".rs")
+
+	result, state := AnalyzeLine("/* one /* two", languageInfo, LineState{})
+	assert.Equal(t, Comment, result)
+	assert.Equal(t, 2, state.BlockCommentDepth)
+
+	// the inner closer only ends the inner comment
+	result, state = AnalyzeLine("*/ let x = 1;", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.Equal(t, 1, state.BlockCommentDepth)
+
+	result, state = AnalyzeLine("*/ let x = 1;", languageInfo, state)
+	assert.Equal(t, Mixed, result)
+	assert.Equal(t, 0, state.BlockCommentDepth)
+
+	// languages without nested comments end the comment at the first closer
+	_, languageInfo, _ = LookupByExtension(".c")
+	result, state = AnalyzeLine("/* one /* two */ int x = 1;", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+	assert.Equal(t, 0, state.BlockCommentDepth)
 }
 
 func Test_scanner_AnalyzeLine_hard(t *testing.T) {
 	testStr := "/* GFLOPS 3.398 x 20 = 67.956 */ {{7, 7}, {{1, 128, 46, 46}}, 128, 1, {1, 1}, {1, 1}, {3, 3}, {0, 0}, \"\", true, 3397788160.},"
 	_, languageInfo, _ := LookupByExtension(".cpp")
-	result, _ := AnalyzeLine(testStr, languageInfo, false)
+	result, _ := AnalyzeLine(testStr, languageInfo, LineState{})
+
+	// Assert
+	assert.Equal(t, Mixed, result)
+
+}
+
+func Test_scanner_AnalyzeLine_comment_markers_in_strings(t *testing.T) {
+	_, languageInfo, _ := LookupByExtension(".c")
+
+	// a block comment opener inside a string must not start a comment
+	result, state := AnalyzeLine("s = \"/*\";", languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+	assert.Equal(t, LineState{}, state)
+
+	result, _ = AnalyzeLine("url = \"http://x\";", languageInfo, state)
+	assert.Equal(t, Code, result)
+
+	// escaped quotes do not end the literal
+	result, state = AnalyzeLine("s = \"\\\" /*\";", languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+	assert.Equal(t, LineState{}, state)
+
+	// char literals are tracked too
+	result, state = AnalyzeLine("c = '\"'; /* comment */", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+	assert.Equal(t, LineState{}, state)
+
+	// a closer inside a string after a comment has ended is ignored
+	result, state = AnalyzeLine("/* a */ s = \"*/\";", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+	assert.Equal(t, LineState{}, state)
+}
+
+func Test_scanner_AnalyzeLine_rust_lifetimes(t *testing.T) {
+	languageInfo := Languages["Rust"]
+
+	// a lifetime does not open a char literal which would hide the comment after it
+	result, state := AnalyzeLine("struct S<'a> { /* start", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+	assert.True(t, state.InBlockComment())
+	result, state = AnalyzeLine("comment", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	result, state = AnalyzeLine("end */", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.Equal(t, LineState{}, state)
+
+	result, state = AnalyzeLine("fn g(x: &'static str) {} // c", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+	assert.Equal(t, LineState{}, state)
+
+	result, _ = AnalyzeLine("fn f<'a, 'b>(x: &'a str) -> &'b str { x } // c", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	result, _ = AnalyzeLine("'outer: loop { break 'outer; } /* c */", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	// char literals are still tracked
+	result, _ = AnalyzeLine("let c = 'a'; let d = '/'; let e = '\\'';", languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+
+	result, state = AnalyzeLine("let q = '\"'; // c", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+	assert.Equal(t, LineState{}, state)
+
+	result, _ = AnalyzeLine("let s = '/*';", languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+}
+
+func Test_scanner_AnalyzeLine_javascript_regex_literals(t *testing.T) {
+	languageInfo := Languages["JavaScript"]
+
+	// a comment opener within a regular expression does not open a comment that hides the following lines
+	result, state := AnalyzeLine(`const re = /\/*/; foo();`, languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+	assert.Equal(t, LineState{}, state)
+	result, state = AnalyzeLine("bar();", languageInfo, state)
+	assert.Equal(t, Code, result)
+	assert.Equal(t, LineState{}, state)
+
+	result, _ = AnalyzeLine(`if (/"/.test(s)) { x(); } // c`, languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	result, _ = AnalyzeLine(`return /[/*]/.test(s);`, languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+
+	result, _ = AnalyzeLine(`s.split(/\/\//); // c`, languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	// a delimiter after a value is division
+	result, _ = AnalyzeLine("x = a / b; // c", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	result, _ = AnalyzeLine("x = (a) / 2 /* c */", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	result, _ = AnalyzeLine(`x = "a" / 2; /* c */`, languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	// comments are not regular expressions
+	result, _ = AnalyzeLine("x = /* c */ 1;", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	result, _ = AnalyzeLine("// c", languageInfo, LineState{})
+	assert.Equal(t, Comment, result)
+}
+
+func Test_scanner_AnalyzeLine_php_attributes(t *testing.T) {
+	languageInfo := Languages["PHP"]
 
 	// Assert
+	result, _ := AnalyzeLine(`#[Route("/x")]`, languageInfo, LineState{})
 	assert.Equal(t, Code, result)
 
+	result, _ = AnalyzeLine(`#[Attribute] // c`, languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+
+	result, _ = AnalyzeLine("# comment", languageInfo, LineState{})
+	assert.Equal(t, Comment, result)
+
+	result, _ = AnalyzeLine("$x = 1; # c", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+}
+
+func Test_scanner_AnalyzeLine_hash_within_a_word(t *testing.T) {
+	tests := []struct {
+		languageName string
+		line         string
+		expected     AnalyzeLineResult
+	}{
+		{"Shell", "n=$#", Code},
+		{"Shell", "echo ${#arr[@]}", Code},
+		{"Shell", "echo ${#x} # count", Mixed},
+		{"Shell", "echo a;# c", Mixed},
+		{"Shell", "# comment", Comment},
+		{"Perl", "my $last = $#array;", Code},
+		{"Perl", "print $#{$ref}; # c", Mixed},
+		{"Ruby", "x = 1 # c", Mixed},
+		{"Python", "x = 1  # c", Mixed},
+	}
+
+	for _, test := range tests {
+		result, _ := AnalyzeLine(test.line, Languages[test.languageName], LineState{})
+
+		// Assert
+		assert.Equal(t, test.expected, result, "%s %q", test.languageName, test.line)
+	}
+}
+
+func Test_scanner_AnalyzeLine_css_has_no_line_comments(t *testing.T) {
+	languageInfo := Languages["CSS"]
+
+	// Assert
+	result, _ := AnalyzeLine("background: url(http://x/y.png);", languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+	result, _ = AnalyzeLine("a { color: red; } /* c */", languageInfo, LineState{})
+	assert.Equal(t, Mixed, result)
+}
+
+func Test_scanner_AnalyzeLine_multi_line_template_literal(t *testing.T) {
+	_, languageInfo, _ := LookupByExtension(".js")
+
+	result, state := AnalyzeLine("const s = `first", languageInfo, LineState{})
+	assert.Equal(t, Code, result)
+	assert.Equal(t, "`", state.OpenString)
+
+	// comment markers inside the template literal are part of the string
+	result, state = AnalyzeLine("// not a comment /*", languageInfo, state)
+	assert.Equal(t, Code, result)
+	assert.Equal(t, "`", state.OpenString)
+
+	result, state = AnalyzeLine("last`; // a comment", languageInfo, state)
+	assert.Equal(t, Mixed, result)
+	assert.Equal(t, LineState{}, state)
+
+	result, _ = AnalyzeLine("// a comment", languageInfo, state)
+	assert.Equal(t, Comment, result)
+}
+
+func Test_scanner_AnalyzeLine_every_comment_syntax(t *testing.T) {
+	tests := []struct {
+		language string
+		lines    []string
+		expected []AnalyzeLineResult
+	}{
+		{"PHP", []string{"// comment", "# comment", "$x = 1; # comment", "/* block", "# still in block */"}, []AnalyzeLineResult{Comment, Comment, Mixed, Comment, Comment}},
+		{"COBOL", []string{"*> comment", "MOVE A TO B. *> comment", "MOVE A TO B."}, []AnalyzeLineResult{Comment, Mixed, Code}},
+		{"Vue", []string{"<!-- comment -->", "<!-- block", "still in block", "-->", "<div></div>"}, []AnalyzeLineResult{Comment, Comment, Comment, Comment, Code}},
+		{"XML", []string{"<!-- block", "-->", "<a/>"}, []AnalyzeLineResult{Comment, Comment, Code}},
+		{"XHTML", []string{"<!-- comment -->", "<p/>"}, []AnalyzeLineResult{Comment, Code}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.language, func(t *testing.T) {
+			languageInfo := Languages[test.language]
+			state := LineState{}
+			for i, line := range test.lines {
+				var result AnalyzeLineResult
+				result, state = AnalyzeLine(line, languageInfo, state)
+				assert.Equal(t, test.expected[i], result, line)
+			}
+		})
+	}
+}
+
+// every language that declares more than one comment syntax must honor all of them
+func Test_scanner_AnalyzeLine_every_comment_syntax_in_languages(t *testing.T) {
+	for languageName, languageInfo := range Languages {
+		if len(languageInfo.LineComments) < 2 && len(languageInfo.MultiLineComments) < 2 {
+			continue
+		}
+		t.Run(languageName, func(t *testing.T) {
+			for _, lineComment := range languageInfo.LineComments {
+				result, state := AnalyzeLine(lineComment+" comment", languageInfo, LineState{})
+				assert.Equal(t, Comment, result, lineComment)
+				// line comments sharing an opener with a block comment carry the block over instead
+				if !state.InBlockComment() {
+					result, _ = AnalyzeLine("code", languageInfo, state)
+					assert.Equal(t, Code, result, lineComment)
+				}
+			}
+			for _, pair := range languageInfo.MultiLineComments {
+				result, state := AnalyzeLine(pair[0]+" comment", languageInfo, LineState{})
+				assert.Equal(t, Comment, result, pair[0])
+				assert.True(t, state.InBlockComment(), pair[0])
+
+				result, state = AnalyzeLine("comment "+pair[1], languageInfo, state)
+				assert.Equal(t, Comment, result, pair[1])
+				assert.False(t, state.InBlockComment(), pair[1])
+
+				result, _ = AnalyzeLine("code", languageInfo, state)
+				assert.Equal(t, Code, result, pair[1])
+			}
+		})
+	}
+}
+
+func Test_scanner_AnalyzeLine_only_matching_closer_ends_block_comment(t *testing.T) {
+	languageInfo := LanguageInfo{
+		LineComments:      []string{"//"},
+		MultiLineComments: [][]string{{"{", "}"}, {"(*", "*)"}},
+	}
+
+	result, state := AnalyzeLine("(* comment with a }", languageInfo, LineState{})
+	assert.Equal(t, Comment, result)
+	assert.True(t, state.InBlockComment())
+	assert.Equal(t, 1, state.BlockCommentIndex)
+
+	result, state = AnalyzeLine("still a comment }", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.True(t, state.InBlockComment())
+
+	result, state = AnalyzeLine("end *) x := 1;", languageInfo, state)
+	assert.Equal(t, Mixed, result)
+	assert.False(t, state.InBlockComment())
+
+	result, state = AnalyzeLine("{ comment with a *)", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.True(t, state.InBlockComment())
+	assert.Equal(t, 0, state.BlockCommentIndex)
+
+	result, state = AnalyzeLine("}", languageInfo, state)
+	assert.Equal(t, Comment, result)
+	assert.False(t, state.InBlockComment())
 }
 
 func Test_scanner_ScanFile_binary(t *testing.T) {
@@ -97,6 +532,64 @@ func Test_scanner_ScanFile_pdf(t *testing.T) {
 
 }
 
+func Test_scanner_ScanFile_binary_with_supported_suffix(t *testing.T) {
+	result := ScanFile("test-files/misc/binary.c")
+
+	// Assert
+	assert.Equal(t, 0, result.CodeLineCount)
+	assert.Equal(t, 0, result.BlankLineCount)
+	assert.Equal(t, "binary: NUL bytes", result.SkipReason)
+}
+
+func Test_scanner_ScanFile_pdf_with_supported_suffix(t *testing.T) {
+	result := ScanFile("test-files/misc/document.js")
+
+	// Assert
+	assert.Equal(t, 0, result.CodeLineCount)
+	assert.Equal(t, 0, result.CommentsLineCount)
+	assert.Equal(t, "binary: PDF signature", result.SkipReason)
+}
+
+func Test_scanner_ScanFile_invalid_utf8_with_supported_suffix(t *testing.T) {
+	result := ScanFile("test-files/misc/noise.js")
+
+	// Assert
+	assert.Equal(t, 0, result.CodeLineCount)
+	assert.Contains(t, result.SkipReason, "invalid UTF-8")
+}
+
+func Test_scanner_ScanFile_text_is_not_skipped(t *testing.T) {
+	result := ScanFile("test-files/misc/blank-file.js")
+
+	// Assert
+	assert.Equal(t, "", result.SkipReason)
+}
+
+func Test_scanner_DetectBinary(t *testing.T) {
+	reason, isBinary := DetectBinary([]byte("\x89PNG\r\n\x1a\n\x00\x00"))
+	assert.True(t, isBinary)
+	assert.Equal(t, "binary: PNG signature", reason)
+
+	reason, isBinary = DetectBinary([]byte("int main() {\x00}"))
+	assert.True(t, isBinary)
+	assert.Equal(t, "binary: NUL bytes", reason)
+
+	// accented text is valid UTF-8
+	_, isBinary = DetectBinary([]byte("// résumé, naïve, 日本語\nfunc main() {}\n"))
+	assert.False(t, isBinary)
+
+	// a few stray invalid bytes are tolerated
+	_, isBinary = DetectBinary([]byte("// caf\xe9 au lait\nfunc main() {}\n"))
+	assert.False(t, isBinary)
+
+	// a rune cut off by the end of the sample is not invalid
+	_, isBinary = DetectBinary([]byte("\xe6\x97"))
+	assert.False(t, isBinary)
+
+	_, isBinary = DetectBinary([]byte{})
+	assert.False(t, isBinary)
+}
+
 func Test_scanner_ScanFile_massive_line_yaml(t *testing.T) {
 	result := ScanFile("test-files/misc/massive-line.yaml")
 
@@ -110,6 +603,635 @@ func Test_scanner_ScanFile_minified_line_txt(t *testing.T) {
 	assert.Equal(t, 1, result.CodeLineCount)
 }
 
+func Test_scanner_ScanFile_generated_go_marker(t *testing.T) {
+	result := ScanFile("test-files/generated/client.gen.ts")
+
+	// Assert
+	assert.True(t, result.IsGenerated)
+	assert.Equal(t, "generated: Code generated ... DO NOT EDIT marker", result.GeneratedReason)
+	// generated files are still counted
+	assert.Equal(t, 14, result.CodeLineCount)
+}
+
+func Test_scanner_ScanFile_generated_protobuf_python(t *testing.T) {
+	result := ScanFile("test-files/generated/user_pb2.py")
+
+	// Assert
+	assert.True(t, result.IsGenerated)
+	assert.Equal(t, "generated: protocol buffer compiler marker", result.GeneratedReason)
+}
+
+func Test_scanner_ScanFile_generated_annotation(t *testing.T) {
+	result := ScanFile("test-files/generated/schema.ts")
+
+	// Assert
+	assert.True(t, result.IsGenerated)
+	assert.Equal(t, "generated: @generated marker", result.GeneratedReason)
+}
+
+func Test_scanner_ScanFile_handwritten_is_not_generated(t *testing.T) {
+	result := ScanFile("test-files/generated/handwritten.py")
+
+	// Assert
+	assert.False(t, result.IsGenerated)
+	assert.Equal(t, "", result.GeneratedReason)
+}
+
+func Test_scanner_ScanFile_minified(t *testing.T) {
+	result := ScanFile("test-files/generated/bundle.min.js")
+
+	// Assert
+	assert.True(t, result.IsGenerated)
+	assert.Equal(t, "minified: average line length 726", result.GeneratedReason)
+}
+
+func Test_scanner_ScanFile_small_files_are_not_minified(t *testing.T) {
+	result := ScanFile("test-files/misc/minified.js")
+
+	// Assert
+	assert.False(t, result.IsGenerated)
+	assert.Equal(t, "", result.GeneratedReason)
+}
+
+func Test_scanner_ScanFile_long_lines_are_not_minified(t *testing.T) {
+	result := ScanFile("test-files/cpp/hard.cpp")
+
+	// Assert
+	assert.False(t, result.IsGenerated)
+}
+
+func Test_scanner_DetectGeneratedMarker(t *testing.T) {
+	assert.Equal(t, "generated: Code generated ... DO NOT EDIT marker", DetectGeneratedMarker("// Code generated by stringer -type=Pill; DO NOT EDIT."))
+	assert.Equal(t, "generated: @generated marker", DetectGeneratedMarker(" * @generated"))
+	assert.Equal(t, "generated: auto-generated marker", DetectGeneratedMarker("# This file is auto-generated, changes will be lost"))
+	// a bare DO NOT EDIT is often written by hand
+	assert.Equal(t, "", DetectGeneratedMarker("// DO NOT EDIT without asking ops"))
+	assert.Equal(t, "", DetectGeneratedMarker("func generate() {}"))
+	assert.Equal(t, "", DetectGeneratedMarker("// do not edit the copy in place"))
+}
+
+func Test_scanner_ScanFile_vue_embedded_languages(t *testing.T) {
+	result := ScanFile("test-files/vue/component.vue")
+
+	// Assert
+	assert.Equal(t, 21, result.CodeLineCount)
+	assert.Equal(t, 7, result.CommentsLineCount)
+	assert.Equal(t, 4, result.BlankLineCount)
+	assert.Equal(t, 2, result.MixedLineCount)
+	// tags and markup outside of blocks stay Vue, nested <template> tags do not end the block
+	assert.Equal(t, map[string]int{"Vue": 6, "HTML": 6, "TypeScript": 6, "Scss": 3}, result.LanguageToCodeLineCount)
+}
+
+func Test_scanner_ScanFile_svelte_embedded_languages(t *testing.T) {
+	result := ScanFile("test-files/svelte/counter.svelte")
+
+	// Assert
+	assert.Equal(t, 14, result.CodeLineCount)
+	assert.Equal(t, 3, result.CommentsLineCount)
+	assert.Equal(t, 3, result.BlankLineCount)
+	assert.Equal(t, map[string]int{"Svelte": 7, "JavaScript": 4, "CSS": 3}, result.LanguageToCodeLineCount)
+}
+
+func Test_scanner_ScanFile_html_embedded_languages(t *testing.T) {
+	result := ScanFile("test-files/html/page.html")
+
+	// Assert
+	assert.Equal(t, 16, result.CodeLineCount)
+	// a <script> within an HTML comment stays a comment
+	assert.Equal(t, 8, result.CommentsLineCount)
+	assert.Equal(t, 0, result.BlankLineCount)
+	assert.Equal(t, map[string]int{"HTML": 13, "CSS": 1, "TypeScript": 2}, result.LanguageToCodeLineCount)
+}
+
+func Test_scanner_ScanFile_single_language_breakdown(t *testing.T) {
+	result := ScanFile("test-files/generated/handwritten.py")
+
+	// Assert
+	assert.Equal(t, map[string]int{"Python": result.CodeLineCount}, result.LanguageToCodeLineCount)
+}
+
+func Test_scanner_AnalyzeEmbeddedLine_regions(t *testing.T) {
+	vue := Languages["Vue"]
+	state := LineState{}
+	var region *embeddedRegion
+
+	result, languageName, state, region := AnalyzeEmbeddedLine(`<script lang="TS">`, "Vue", vue, state, region)
+	assert.Equal(t, Code, result)
+	assert.Equal(t, "Vue", languageName)
+	assert.Equal(t, "TypeScript", region.languageName)
+
+	result, languageName, state, region = AnalyzeEmbeddedLine("// comment", "Vue", vue, state, region)
+	assert.Equal(t, Comment, result)
+	assert.Equal(t, "TypeScript", languageName)
+
+	result, languageName, state, region = AnalyzeEmbeddedLine("</SCRIPT>", "Vue", vue, state, region)
+	assert.Equal(t, Code, result)
+	assert.Equal(t, "Vue", languageName)
+	assert.Nil(t, region)
+
+	// unknown lang attributes fall back to the default language of the tag
+	_, _, state, region = AnalyzeEmbeddedLine(`<style lang="stylus">`, "Vue", vue, state, region)
+	assert.Equal(t, "CSS", region.languageName)
+	_, _, state, region = AnalyzeEmbeddedLine("</style>", "Vue", vue, state, region)
+	assert.Nil(t, region)
+
+	// self closing and single line blocks do not open a region
+	_, _, state, region = AnalyzeEmbeddedLine(`<script src="app.js" />`, "Vue", vue, state, region)
+	assert.Nil(t, region)
+	_, _, _, region = AnalyzeEmbeddedLine(`<style>.a { color: red; }</style>`, "Vue", vue, state, region)
+	assert.Nil(t, region)
+}
+
+func Test_scanner_ScanFile_notebook(t *testing.T) {
+	result := ScanFile("test-files/jupyter/analysis.ipynb")
+
+	// Assert
+	assert.Equal(t, "Jupyter Notebook", result.LanguageName)
+	assert.Equal(t, "", result.SkipReason)
+	// outputs and raw cells are ignored
+	assert.Equal(t, 6, result.CodeLineCount)
+	// markdown cells are documentation
+	assert.Equal(t, 3, result.CommentsLineCount)
+	assert.Equal(t, 2, result.BlankLineCount)
+	assert.Equal(t, 1, result.MixedLineCount)
+	assert.Equal(t, map[string]int{"Python": 6}, result.LanguageToCodeLineCount)
+}
+
+func Test_scanner_ScanFile_notebook_string_source(t *testing.T) {
+	result := ScanFile("test-files/jupyter/scala-kernel.ipynb")
+
+	// Assert
+	assert.Equal(t, 2, result.CodeLineCount)
+	assert.Equal(t, 2, result.CommentsLineCount)
+	assert.Equal(t, 1, result.BlankLineCount)
+	assert.Equal(t, map[string]int{"Scala": 2}, result.LanguageToCodeLineCount)
+}
+
+func Test_scanner_ScanFile_notebook_unsupported_kernel(t *testing.T) {
+	result := ScanFile("test-files/jupyter/unknown-kernel.ipynb")
+
+	// Assert
+	assert.Equal(t, 2, result.CodeLineCount)
+	assert.Equal(t, 0, result.CommentsLineCount)
+	assert.Equal(t, map[string]int{"Jupyter Notebook": 2}, result.LanguageToCodeLineCount)
+}
+
+func Test_scanner_ScanFile_notebook_invalid(t *testing.T) {
+	result := ScanFile("test-files/jupyter/truncated.ipynb")
+
+	// Assert
+	assert.Equal(t, 0, result.CodeLineCount)
+	assert.Contains(t, result.SkipReason, "invalid notebook")
+}
+
+func Test_scanner_ScanFile_cobol_fixed_format(t *testing.T) {
+	result := ScanFile("test-files/cobol/payroll.cbl")
+
+	// Assert
+	assert.Equal(t, 10, result.CodeLineCount)
+	// * and / in the indicator column and floating *> comments
+	assert.Equal(t, 3, result.CommentsLineCount)
+	assert.Equal(t, 1, result.MixedLineCount)
+	// a line with only a sequence number is blank
+	assert.Equal(t, 1, result.BlankLineCount)
+}
+
+func Test_scanner_ScanFile_fortran_fixed_form(t *testing.T) {
+	result := ScanFile("test-files/fortran/area.f")
+
+	// Assert
+	assert.Equal(t, "Fortran 77", result.LanguageName)
+	assert.Equal(t, 9, result.CodeLineCount)
+	assert.Equal(t, 4, result.CommentsLineCount)
+	assert.Equal(t, 1, result.MixedLineCount)
+	assert.Equal(t, 1, result.BlankLineCount)
+}
+
+func Test_scanner_ScanFile_fortran_free_form(t *testing.T) {
+	result := ScanFile("test-files/fortran/area.f90")
+
+	// Assert
+	assert.Equal(t, "Fortran", result.LanguageName)
+	assert.Equal(t, 7, result.CodeLineCount)
+	assert.Equal(t, 1, result.CommentsLineCount)
+	assert.Equal(t, 1, result.MixedLineCount)
+	assert.Equal(t, 1, result.BlankLineCount)
+}
+
+func Test_scanner_ScanFile_rpg_fixed_format(t *testing.T) {
+	result := ScanFile("test-files/rpg/orders.rpg")
+
+	// Assert
+	assert.Equal(t, 5, result.CodeLineCount)
+	assert.Equal(t, 2, result.CommentsLineCount)
+	assert.Equal(t, 1, result.BlankLineCount)
+}
+
+func Test_scanner_ApplyColumnRules(t *testing.T) {
+	cobol := Languages["COBOL"]
+
+	line, isComment := ApplyColumnRules(fmt.Sprintf("000100 %-65sSEQ00001\n", "MOVE A TO B."), cobol)
+	assert.False(t, isComment)
+	assert.Equal(t, "MOVE A TO B.", strings.TrimSpace(line))
+
+	// the indicator only counts in its column
+	_, isComment = ApplyColumnRules("000200*COMMENT", cobol)
+	assert.True(t, isComment)
+	_, isComment = ApplyColumnRules("000300     MULTIPLY A BY B", cobol)
+	assert.False(t, isComment)
+	_, isComment = ApplyColumnRules("*", cobol)
+	assert.False(t, isComment)
+
+	// columns are counted in characters
+	line, _ = ApplyColumnRules("00040é MOVE \"é\" TO B.", cobol)
+	assert.Equal(t, "MOVE \"é\" TO B.", strings.TrimSpace(line))
+
+	// languages without column rules are left untouched
+	line, isComment = ApplyColumnRules("*  x", Languages["Python"])
+	assert.False(t, isComment)
+	assert.Equal(t, "*  x", line)
+}
+
+func Test_scanner_ScanFile_utf16(t *testing.T) {
+	for _, test := range []struct {
+		filePath string
+		encoding Encoding
+	}{
+		{"test-files/encoding/utf16le-bom.cs", EncodingUTF16LE},
+		{"test-files/encoding/utf16be-bom.cs", EncodingUTF16BE},
+		{"test-files/encoding/utf16le.cs", EncodingUTF16LE},
+	} {
+		t.Run(test.filePath, func(t *testing.T) {
+			result := ScanFile(test.filePath)
+
+			// Assert
+			assert.Equal(t, "", result.SkipReason)
+			assert.Equal(t, test.encoding, result.Encoding)
+			assert.Equal(t, 8, result.CodeLineCount)
+			assert.Equal(t, 2, result.CommentsLineCount)
+			assert.Equal(t, 1, result.MixedLineCount)
+			assert.Equal(t, 1, result.BlankLineCount)
+		})
+	}
+}
+
+func Test_scanner_ScanFile_utf8_bom(t *testing.T) {
+	result := ScanFile("test-files/encoding/utf8-bom.js")
+
+	// Assert
+	assert.Equal(t, EncodingUTF8BOM, result.Encoding)
+	// the byte order mark does not turn the comment into code
+	assert.Equal(t, 1, result.CommentsLineCount)
+	assert.Equal(t, 1, result.CodeLineCount)
+}
+
+func Test_scanner_ScanFile_windows_1252(t *testing.T) {
+	result := ScanFile("test-files/encoding/windows-1252.py")
+
+	// Assert
+	assert.Equal(t, "", result.SkipReason)
+	assert.Equal(t, EncodingWindows1252, result.Encoding)
+	assert.Equal(t, 1, result.CommentsLineCount)
+	assert.Equal(t, 2, result.CodeLineCount)
+	// a line with only a non-breaking space is blank once decoded
+	assert.Equal(t, 2, result.BlankLineCount)
+}
+
+func Test_scanner_ScanFile_forced_encoding(t *testing.T) {
+	FileEncoding = EncodingLatin1
+	defer func() { FileEncoding = EncodingAuto }()
+	result := ScanFile("test-files/encoding/windows-1252.py")
+
+	// Assert
+	assert.Equal(t, EncodingLatin1, result.Encoding)
+	assert.Equal(t, 2, result.CodeLineCount)
+	assert.Equal(t, 2, result.BlankLineCount)
+}
+
+func Test_scanner_DetectEncoding(t *testing.T) {
+	encoding, byteOrderMarkLength := DetectEncoding([]byte("plain ascii"))
+	assert.Equal(t, EncodingUTF8, encoding)
+	assert.Equal(t, 0, byteOrderMarkLength)
+
+	encoding, byteOrderMarkLength = DetectEncoding([]byte("\xef\xbb\xbfx"))
+	assert.Equal(t, EncodingUTF8BOM, encoding)
+	assert.Equal(t, 3, byteOrderMarkLength)
+
+	encoding, _ = DetectEncoding([]byte("\x00i\x00n\x00t\x00 \x00x\x00;"))
+	assert.Equal(t, EncodingUTF16BE, encoding)
+
+	// a UTF-8 character cut off by the end of the sample
+	encoding, _ = DetectEncoding([]byte("caf\xc3"))
+	assert.Equal(t, EncodingUTF8, encoding)
+
+	encoding, _ = DetectEncoding([]byte("caf\xe9 au lait"))
+	assert.Equal(t, EncodingWindows1252, encoding)
+
+	// binary data with NUL code units is not UTF-16
+	encoding, _ = DetectEncoding([]byte("\x01\x00\x00\x00\x02\x00\x00\x00"))
+	assert.NotEqual(t, EncodingUTF16LE, encoding)
+}
+
+func Test_scanner_NewDecodingReader_broken_surrogate_pair(t *testing.T) {
+	// a high surrogate followed by a line break keeps the line break
+	reader := NewDecodingReader(bufio.NewReader(strings.NewReader("\x3d\xd8\n\x00a\x00")), EncodingUTF16LE)
+	decoded, err := io.ReadAll(reader)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "�\na", string(decoded))
+}
+
+func Test_scanner_ScanFile_line_endings(t *testing.T) {
+	tests := []struct {
+		filePath   string
+		lineEnding LineEnding
+		blank      int
+		totalLines int
+	}{
+		{"test-files/line-endings/unix.c", LineEndingLF, 1, 6},
+		{"test-files/line-endings/windows.c", LineEndingCRLF, 1, 6},
+		{"test-files/line-endings/classic-mac.c", LineEndingCR, 1, 6},
+		{"test-files/line-endings/mixed.c", LineEndingMixed, 1, 6},
+		{"test-files/line-endings/no-final-newline.c", LineEndingLF, 1, 6},
+		// the empty lines before the end of the file are counted, but not an empty line after the last line ending
+		{"test-files/line-endings/trailing-blank-lines.c", LineEndingLF, 3, 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filePath, func(t *testing.T) {
+			result := ScanFile(test.filePath)
+
+			// Assert
+			assert.Equal(t, test.lineEnding, result.LineEnding)
+			assert.Equal(t, 4, result.CodeLineCount)
+			assert.Equal(t, 1, result.CommentsLineCount)
+			assert.Equal(t, 1, result.MixedLineCount)
+			assert.Equal(t, test.blank, result.BlankLineCount)
+			assert.Equal(t, test.totalLines, result.TotalLines)
+		})
+	}
+}
+
+func Test_scanner_ScanFile_total_lines_empty_file(t *testing.T) {
+	result := ScanFile("test-files/misc/blank-file.js")
+
+	// Assert
+	assert.Equal(t, 0, result.TotalLines)
+	assert.Equal(t, 0, result.BlankLineCount)
+	assert.Equal(t, LineEndingNone, result.LineEnding)
+}
+
+func Test_scanner_LineReader(t *testing.T) {
+	tests := []struct {
+		input      string
+		lines      []string
+		lineEnding LineEnding
+	}{
+		{"", []string{}, LineEndingNone},
+		{"a", []string{"a"}, LineEndingNone},
+		{"\n", []string{""}, LineEndingLF},
+		{"a\nb\n", []string{"a", "b"}, LineEndingLF},
+		{"a\r\n\r\nb", []string{"a", "", "b"}, LineEndingCRLF},
+		{"a\rb\r", []string{"a", "b"}, LineEndingCR},
+		{"a\r\rb", []string{"a", "", "b"}, LineEndingCR},
+		{"a\r\nb\nc\r", []string{"a", "b", "c"}, LineEndingMixed},
+		// a line longer than the buffer of the reader
+		{strings.Repeat("x", 100) + "\r\n", []string{strings.Repeat("x", 100)}, LineEndingCRLF},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%q", test.input), func(t *testing.T) {
+			lineReader := NewLineReader(bufio.NewReaderSize(strings.NewReader(test.input), 16))
+			lines := []string{}
+			for {
+				line, err := lineReader.ReadLine()
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				lines = append(lines, line)
+			}
+
+			// Assert
+			assert.Equal(t, test.lines, lines)
+			assert.Equal(t, test.lineEnding, lineReader.LineEnding())
+		})
+	}
+}
+
+func Test_scanner_LineReader_ReadLineChunk(t *testing.T) {
+	lineReader := NewLineReader(bufio.NewReaderSize(strings.NewReader("abcdefgh\r\nij\nklm"), 16))
+	lineReader.chunkBytes = 3
+
+	type chunk struct {
+		text      string
+		isLineEnd bool
+	}
+	chunks := []chunk{}
+	carry := []byte{}
+	for {
+		text, isLineEnd, err := lineReader.ReadLineChunk(carry)
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		chunks = append(chunks, chunk{string(text), isLineEnd})
+		// the last byte of a chunk is carried over into the next chunk of the same line
+		carry = nil
+		if !isLineEnd {
+			carry = text[len(text)-1:]
+		}
+	}
+
+	// Assert
+	assert.Equal(t, []chunk{{"abc", false}, {"cdef", false}, {"fgh", true}, {"ij", true}, {"klm", false}, {"m", true}}, chunks)
+	assert.Equal(t, LineEndingMixed, lineReader.LineEnding())
+}
+
+// lexes a line in chunks of the given size, the way ScanFile does for lines longer than LineChunkBytes
+func analyzeLineInChunks(line string, chunkBytes int, languageInfo LanguageInfo, state LineState) (AnalyzeLineResult, LineState) {
+	lineReader := NewLineReader(bufio.NewReader(strings.NewReader(line)))
+	lineReader.chunkBytes = chunkBytes
+	chunk, isLineEnd, err := lineReader.ReadLineChunk(nil)
+	if err == io.EOF {
+		return AnalyzeLine("", languageInfo, state)
+	}
+	if isLineEnd {
+		return AnalyzeLine(string(chunk), languageInfo, state)
+	}
+	lineResult, state, _, _ := scanLongLine(lineReader, chunk, false, &languageInfo, state)
+	return lineResult, state
+}
+
+func Test_scanner_lexing_in_chunks_matches_AnalyzeLine(t *testing.T) {
+	for _, filePath := range WalkDirectory("test-files", []IgnoreFile{}) {
+		_, languageInfo, _, _ := DetectLanguage(filePath)
+		content, err := os.ReadFile(filePath)
+		assert.Nil(t, err)
+		lines := []string{}
+		lineReader := NewLineReader(bufio.NewReader(bytes.NewReader(content)))
+		for line, err := lineReader.ReadLine(); err == nil; line, err = lineReader.ReadLine() {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+
+		for _, chunkBytes := range []int{1, 2, 3, 8, 64} {
+			state, chunkedState := LineState{}, LineState{}
+			for lineNumber, line := range lines {
+				var lineResult, chunkedLineResult AnalyzeLineResult
+				lineResult, state = AnalyzeLine(line, languageInfo, state)
+				chunkedLineResult, chunkedState = analyzeLineInChunks(line, chunkBytes, languageInfo, chunkedState)
+
+				// Assert
+				if !assert.Equal(t, lineResult, chunkedLineResult, "%s:%d in chunks of %d", filePath, lineNumber+1, chunkBytes) ||
+					!assert.Equal(t, state, chunkedState, "%s:%d in chunks of %d", filePath, lineNumber+1, chunkBytes) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func Test_scanner_lexing_in_chunks_tokens_across_chunks(t *testing.T) {
+	tests := []struct {
+		languageName string
+		lines        []string
+	}{
+		{"Python", []string{`"""doc""".strip()`, `"""doc"""   # comment`, `"""doc"""`, `x = rb"a\"b" # c`, `"""doc`, `more""" + x`, `x = (`, `"""not a docstring"""`, `)`, `y = \`, `"""continued"""`}},
+		{"C", []string{`x = "a\\" // c`, `/* a */ b /* c`, `still */ d`, `'\'' /**/`, `"unterminated // x`}},
+		{"Haskell", []string{`{- a {- nested -} still -} x`, `x -- c`}},
+		{"Rust", []string{`let s = r#"a "# // c`, `fn f<'a>(x: &'a str) { /* c`, `*/ 'x' }`, `'l: loop {} // c`}},
+		{"Shell", []string{`n=$#`, `echo ${#x} # count`, `echo a;# c`}},
+		{"PHP", []string{`#[Route("/x")]`, `$x = 1; # c`}},
+		{"JavaScript", []string{`const re = /\/*/; foo();`, `bar();`, `return /[/*]/.test(s) // c`, `x = a / b /* c */`, `typeof /a/ // c`}},
+	}
+
+	for _, test := range tests {
+		languageInfo := Languages[test.languageName]
+		for chunkBytes := 1; chunkBytes <= 8; chunkBytes++ {
+			state, chunkedState := LineState{}, LineState{}
+			for _, line := range test.lines {
+				var lineResult, chunkedLineResult AnalyzeLineResult
+				lineResult, state = AnalyzeLine(line, languageInfo, state)
+				chunkedLineResult, chunkedState = analyzeLineInChunks(line, chunkBytes, languageInfo, chunkedState)
+
+				// Assert
+				assert.Equal(t, lineResult, chunkedLineResult, "%s %q in chunks of %d", test.languageName, line, chunkBytes)
+				assert.Equal(t, state, chunkedState, "%s %q in chunks of %d", test.languageName, line, chunkBytes)
+			}
+		}
+	}
+}
+
+// repeatingReader returns the pattern over and over until it has returned size bytes, without holding them in memory
+type repeatingReader struct {
+	pattern string
+	size    int
+	offset  int
+}
+
+func (reader *repeatingReader) Read(p []byte) (int, error) {
+	if reader.offset >= reader.size {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && reader.offset < reader.size {
+		copied := copy(p[n:min(len(p), n+reader.size-reader.offset)], reader.pattern[reader.offset%len(reader.pattern):])
+		n += copied
+		reader.offset += copied
+	}
+	return n, nil
+}
+
+func Test_scanner_ScanFile_very_long_line_uses_bounded_memory(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("lexes a line of hundreds of MB")
+	}
+	logLevel := logger.GetLogLevel()
+	logger.SetLogLevel(logger.INFO)
+	defer logger.SetLogLevel(logLevel)
+	languageName := "JavaScript"
+	languageInfo := Languages[languageName]
+	// a single line JSON dump assigned to a variable, the // in its strings are not comments
+	newContent := func() io.Reader {
+		return io.MultiReader(
+			strings.NewReader("/* dump */ const data = ["),
+			&repeatingReader{pattern: `{"url": "https://example.com/a", "escaped": "\"//", "n": 1}, `, size: 300 * 1024 * 1024},
+			strings.NewReader("]; // end of dump\nconsole.log(data)\n"),
+		)
+	}
+
+	for _, maxLineLength := range []int{0, 1000} {
+		t.Run(fmt.Sprint("max line length ", maxLineLength), func(t *testing.T) {
+			MaxLineLength = maxLineLength
+			defer func() { MaxLineLength = 0 }()
+			memStats := runtime.MemStats{}
+			runtime.ReadMemStats(&memStats)
+			allocatedBefore := memStats.TotalAlloc
+
+			result := scanContent(newContent(), FileScanResults{FilePath: "dump.js"}, languageName, languageInfo, "extension .js")
+
+			runtime.ReadMemStats(&memStats)
+			allocated := memStats.TotalAlloc - allocatedBefore
+			// Assert
+			assert.Less(t, allocated, uint64(16*1024*1024))
+			assert.Equal(t, "", result.SkipReason)
+			assert.Equal(t, 2, result.TotalLines)
+			if maxLineLength == 0 {
+				assert.Equal(t, 2, result.CodeLineCount)
+				assert.Equal(t, 1, result.MixedLineCount)
+				assert.Equal(t, 0, result.DataLineCount)
+			} else {
+				assert.Equal(t, 1, result.CodeLineCount)
+				assert.Equal(t, 0, result.MixedLineCount)
+				assert.Equal(t, 1, result.DataLineCount)
+			}
+		})
+	}
+}
+
+func Test_scanner_ScanFile_max_line_length(t *testing.T) {
+	MaxLineLength = 100
+	defer func() { MaxLineLength = 0 }()
+	result := ScanFile("test-files/misc/minified.js")
+
+	// Assert
+	assert.Equal(t, 1, result.DataLineCount)
+	assert.Equal(t, 0, result.CodeLineCount)
+	assert.Equal(t, 1, result.TotalLines)
+}
+
+func Test_scanner_ScanDirectoriesToChannel_matches_serial_scan(t *testing.T) {
+	serialResults := map[string]FileScanResults{}
+	for _, filePath := range WalkDirectory("test-files", []IgnoreFile{}) {
+		serialResults[filePath] = ScanFile(filePath)
+	}
+
+	// the CLI passes 0 for the number of CPUs available, the pool does the same for any value below 1
+	for _, workers := range []int{1, 3, 16, 0, -1} {
+		t.Run(fmt.Sprint(workers, " workers"), func(t *testing.T) {
+			// results arrive in the order the files finish
+			streamedResults := map[string]FileScanResults{}
+			for result := range ScanDirectoriesToChannel([]string{"test-files"}, []IgnoreFile{}, workers) {
+				streamedResults[result.FilePath] = result
+			}
+
+			// Assert
+			assert.Equal(t, serialResults, streamedResults)
+		})
+	}
+}
+
+func Test_scanner_ScanDirectoriesToChannel_no_files(t *testing.T) {
+	results := []FileScanResults{}
+	for result := range ScanDirectoriesToChannel([]string{t.TempDir()}, []IgnoreFile{}, 4) {
+		results = append(results, result)
+	}
+
+	// Assert
+	assert.Equal(t, []FileScanResults{}, results)
+}
+
 func Test_scanner_ScanFile_dockerfile(t *testing.T) {
 	result := ScanFile("test-files/docker/by-suffix/test.Dockerfile")
 
@@ -124,6 +1246,142 @@ func Test_scanner_ScanFile_dockerfile_no_suffix(t *testing.T) {
 	// Assert
 	assert.Equal(t, 2, result.CodeLineCount)
 }
+func Test_scanner_ScanFile_scripts_without_suffix(t *testing.T) {
+	tests := []struct {
+		filePath     string
+		languageName string
+		code         int
+		comments     int
+	}{
+		{"test-files/scripts/deploy", "Python", 2, 2},
+		{"test-files/scripts/build", "Shell", 2, 2},
+		{"test-files/scripts/release", "Ruby", 2, 1},
+		{"test-files/scripts/report", "Perl", 2, 1},
+		{"test-files/scripts/notes", "", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filePath, func(t *testing.T) {
+			result := ScanFile(test.filePath)
+
+			// Assert
+			assert.Equal(t, test.languageName, result.LanguageName)
+			assert.Equal(t, test.code, result.CodeLineCount)
+			assert.Equal(t, test.comments, result.CommentsLineCount)
+		})
+	}
+}
+
+func Test_scanner_parseShebang(t *testing.T) {
+	tests := map[string]string{
+		"#!/bin/bash":                          "bash",
+		"#! /usr/bin/perl -w":                  "perl",
+		"#!/usr/bin/env python3":               "python3",
+		"#!/usr/bin/env -S node --no-warnings": "node",
+		"#!/usr/bin/env LANG=C ruby":           "ruby",
+	}
+	for line, expected := range tests {
+		interpreter, found := parseShebang(line)
+		assert.True(t, found, line)
+		assert.Equal(t, expected, interpreter, line)
+	}
+
+	_, found := parseShebang("# not a shebang")
+	assert.False(t, found)
+}
+
+func Test_scanner_parseModelines(t *testing.T) {
+	fileType, found := parseVimModeline("# vim: set ft=ruby:")
+	assert.True(t, found)
+	assert.Equal(t, "ruby", fileType)
+
+	fileType, found = parseVimModeline("// vi: filetype=javascript")
+	assert.True(t, found)
+	assert.Equal(t, "javascript", fileType)
+
+	mode, found := parseEmacsModeline("# -*- mode: perl; coding: utf-8 -*-")
+	assert.True(t, found)
+	assert.Equal(t, "perl", mode)
+
+	mode, found = parseEmacsModeline("/* -*- C++ -*- */")
+	assert.True(t, found)
+	assert.Equal(t, "C++", mode)
+
+	_, found = parseVimModeline("the vim editor")
+	assert.False(t, found)
+}
+
+func Test_scanner_LookupByInterpreter(t *testing.T) {
+	language, _, found := LookupByInterpreter("python3.11")
+	assert.True(t, found)
+	assert.Equal(t, "Python", language)
+
+	language, _, found = LookupByModeline("cpp")
+	assert.True(t, found)
+	assert.Equal(t, "C++", language)
+
+	language, _, found = LookupByModeline("sh")
+	assert.True(t, found)
+	assert.Equal(t, "Shell", language)
+
+	_, _, found = LookupByInterpreter("awk")
+	assert.False(t, found)
+}
+
+func Test_scanner_DetectLanguage_shared_extensions(t *testing.T) {
+	tests := []struct {
+		filePath     string
+		languageName string
+		rule         string
+	}{
+		{"test-files/ambiguous/plain.h", "C Header", "priority for .h over Objective-C, C++ Header"},
+		{"test-files/ambiguous/widget.h", "C++ Header", "heuristic"},
+		{"test-files/ambiguous/view.h", "Objective-C", "heuristic"},
+		{"test-files/ambiguous/view.m", "Objective-C", "heuristic"},
+		{"test-files/ambiguous/area.m", "MATLAB", "heuristic"},
+		{"test-files/ambiguous/config.inc", "PHP", "heuristic"},
+		{"test-files/ambiguous/helpers.inc", "Pascal", "heuristic"},
+		{"test-files/ambiguous/empty.inc", "PHP", "priority for .inc over Pascal"},
+		{"test-files/ambiguous/stage.as", "ActionScript", "heuristic"},
+		{"test-files/ambiguous/app.as", "Flex", "heuristic"},
+		{"test-files/c/hard.c", "C", "extension .c"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filePath, func(t *testing.T) {
+			// every run must resolve to the same language
+			for range 20 {
+				languageName, _, rule, found := DetectLanguage(test.filePath)
+
+				// Assert
+				assert.True(t, found)
+				assert.Equal(t, test.languageName, languageName)
+				assert.Contains(t, rule, test.rule)
+			}
+		})
+	}
+}
+
+func Test_scanner_ScanFile_records_detection_rule(t *testing.T) {
+	result := ScanFile("test-files/ambiguous/helpers.inc")
+
+	// Assert
+	assert.Equal(t, "Pascal", result.LanguageName)
+	assert.Contains(t, result.DetectionRule, "heuristic")
+	assert.Equal(t, 4, result.CodeLineCount)
+	assert.Equal(t, 1, result.CommentsLineCount)
+}
+
+func Test_scanner_LookupByExtension_priority(t *testing.T) {
+	for range 20 {
+		language, _, found := LookupByExtension(".as")
+
+		// Assert
+		assert.True(t, found)
+		assert.Equal(t, "ActionScript", language)
+	}
+}
+
 func Test_scanner_ParseFileSuffix(t *testing.T) {
 	suffix := ParseFileSuffix("main.js")
 
@@ -134,32 +1392,69 @@ func Test_scanner_ParseFileSuffix(t *testing.T) {
 	assert.Equal(t, ".jcl", suffix)
 }
 func Test_scanner_WalkDirectory_no_ignores(t *testing.T) {
-	ignorePatterns := []string{}
+	ignoreFiles := []IgnoreFile{}
 
-	result := WalkDirectory("test-files/js", ignorePatterns)
+	result := WalkDirectory("test-files/js", ignoreFiles)
 
 	// Assert
 	assert.Equal(t, 2, len(result))
 }
 
 func Test_scanner_WalkDirectory_with_ignores(t *testing.T) {
-	ignorePatterns := []string{"*easy.js"}
+	ignoreFiles := []IgnoreFile{NewIgnoreFile("test-files/js", []string{"*easy.js"})}
 
-	result := WalkDirectory("test-files/js", ignorePatterns)
+	result := WalkDirectory("test-files/js", ignoreFiles)
 
 	// Assert
 	assert.Equal(t, 1, len(result))
 }
 
 func Test_scanner_WalkDirectory_containing_with_files_without_suffix(t *testing.T) {
-	ignorePatterns := []string{}
+	ignoreFiles := []IgnoreFile{}
 
-	result := WalkDirectory("test-files/docker", ignorePatterns)
+	result := WalkDirectory("test-files/docker", ignoreFiles)
 
 	// Assert
 	assert.Equal(t, 2, len(result))
 }
 
+func Test_scanner_WalkDirectory_detects_scripts_without_suffix(t *testing.T) {
+	ignoreFiles := []IgnoreFile{}
+
+	result := WalkDirectory("test-files/scripts", ignoreFiles)
+
+	// Assert
+	assert.Equal(t, 4, len(result))
+}
+
+func Test_scanner_walkDirectories_finds_each_file_once(t *testing.T) {
+	result := walkDirectories([]string{"test-files/js", "test-files/docker", "test-files", "test-files/js"}, []IgnoreFile{})
+
+	// Assert
+	expected := WalkDirectory("test-files", []IgnoreFile{})
+	assert.ElementsMatch(t, expected, result)
+	// the files are found in the order the directories were given
+	assert.Equal(t, WalkDirectory("test-files/js", []IgnoreFile{}), result[:2])
+}
+
+func Test_scanner_targetPathsOverlap(t *testing.T) {
+	testCases := []struct {
+		targetPaths []string
+		expected    bool
+	}{
+		{[]string{"test-files"}, false},
+		{[]string{"test-files/js", "test-files/docker"}, false},
+		{[]string{"test-files/js", "test-files/js-extra"}, false},
+		{[]string{"test-files/js", "test-files/js"}, true},
+		{[]string{"test-files/js", "test-files"}, true},
+		{[]string{"test-files", "test-files/docker/../js"}, true},
+	}
+	for _, testCase := range testCases {
+		// Assert
+		assert.Equal(t, testCase.expected, targetPathsOverlap(testCase.targetPaths), testCase.targetPaths)
+	}
+}
+
 func Test_scanner_ReadIgnoreFile(t *testing.T) {
 
 	result := ReadIgnoreFile("test-files/test-ignore-file.txt")
@@ -180,3 +1475,20 @@ func Test_scanner_LoadLanguages(t *testing.T) {
 	assert.Equal(t, "YAML", language)
 	assert.Equal(t, true, found)
 }
+
+func Test_scanner_LoadLanguages_new_language(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(configPath, []byte(`{"Zig": {"LineComments": ["//"], "Extensions": [".zig"]}}`), 0644))
+	defer func() {
+		delete(Languages, "Zig")
+		languageNames = sortLanguageNames(Languages)
+	}()
+
+	LoadLanguages(configPath)
+	// lookups by name go through the sorted language names
+	language, _, found := LookupByModeline("zig")
+
+	// Assert
+	assert.Equal(t, "Zig", language)
+	assert.Equal(t, true, found)
+}
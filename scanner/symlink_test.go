@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// creates a repo directory with links to a shared directory outside of it, to a file inside of it and to itself
+func writeTreeWithSymlinks(t *testing.T) string {
+	t.Helper()
+	root := writeTree(t, map[string]string{
+		"real/shared/util.js": "x = 1\n",
+		"repo/app.js":         "x = 1\ny = 2\n",
+	})
+	links := map[string]string{
+		"repo/shared":       filepath.Join("..", "real", "shared"),
+		"repo/shared-again": filepath.Join("..", "real", "shared"),
+		"repo/loop":         ".",
+		"repo/copy.js":      "app.js",
+		"repo/broken.js":    "missing.js",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link))); err != nil {
+			t.Skip("symbolic links are not supported: ", err)
+		}
+	}
+	root, err := filepath.Abs(root)
+	assert.Nil(t, err)
+	return root
+}
+
+func sortedKeys(results map[string]FileScanResults) []string {
+	keys := []string{}
+	for key := range results {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func Test_symlink_WalkDirectory_does_not_follow_links_by_default(t *testing.T) {
+	root := writeTreeWithSymlinks(t)
+
+	result := walkRelative(t, root, filepath.Join(root, "repo"))
+
+	// Assert
+	// links to files are scanned either way, the broken link cannot be
+	assert.Equal(t, []string{"repo/app.js", "repo/broken.js", "repo/copy.js"}, result)
+}
+
+func Test_symlink_ScanDirectoriesToChannel_follows_links(t *testing.T) {
+	root := writeTreeWithSymlinks(t)
+	FollowSymlinks = true
+	defer func() { FollowSymlinks = false }()
+
+	results := map[string]FileScanResults{}
+	for result := range ScanDirectoriesToChannel([]string{filepath.Join(root, "repo")}, []IgnoreFile{}, 2) {
+		relPath, err := filepath.Rel(root, result.FilePath)
+		assert.Nil(t, err)
+		results[filepath.ToSlash(relPath)] = result
+	}
+
+	// Assert
+	realRoot, err := filepath.EvalSymlinks(root)
+	assert.Nil(t, err)
+	// each real file is counted once, under the first path it is found at, and the link back to repo does not loop
+	// copy.js links to app.js which was found first, shared-again links to the directory already walked through shared
+	assert.Equal(t, []string{"repo/app.js", "repo/shared/util.js"}, sortedKeys(results))
+	assert.Equal(t, "", results["repo/app.js"].RealPath)
+	assert.Equal(t, 2, results["repo/app.js"].CodeLineCount)
+	assert.Equal(t, filepath.Join(realRoot, "real", "shared", "util.js"), results["repo/shared/util.js"].RealPath)
+	assert.Equal(t, 1, results["repo/shared/util.js"].CodeLineCount)
+}
+
+func Test_symlink_WalkDirectory_counts_files_reached_from_several_roots_once(t *testing.T) {
+	root := writeTreeWithSymlinks(t)
+	FollowSymlinks = true
+	defer func() { FollowSymlinks = false }()
+
+	result := walkDirectories([]string{filepath.Join(root, "real"), filepath.Join(root, "repo")}, []IgnoreFile{})
+
+	// Assert
+	assert.Equal(t, []string{filepath.Join(root, "real", "shared", "util.js"), filepath.Join(root, "repo", "app.js")}, result)
+}
+
+func Test_symlink_WalkDirectory_counts_a_root_given_through_a_link_and_by_its_real_path_once(t *testing.T) {
+	root := writeTreeWithSymlinks(t)
+
+	// with a trailing separator the directory behind the link is walked without --follow-symlinks
+	result := walkDirectories([]string{filepath.Join(root, "repo", "shared") + string(filepath.Separator), filepath.Join(root, "real", "shared")}, []IgnoreFile{})
+
+	// Assert
+	assert.Equal(t, []string{filepath.Join(root, "repo", "shared", "util.js")}, result)
+}
+
+func Test_symlink_WalkDirectory_follows_a_linked_target(t *testing.T) {
+	root := writeTreeWithSymlinks(t)
+	FollowSymlinks = true
+	defer func() { FollowSymlinks = false }()
+
+	result := walkRelative(t, root, filepath.Join(root, "repo", "shared"))
+
+	// Assert
+	assert.Equal(t, []string{"repo/shared/util.js"}, result)
+}
+
+func Test_symlink_WalkDirectory_file_link_found_before_the_file(t *testing.T) {
+	root := writeTreeWithSymlinks(t)
+	assert.Nil(t, os.Symlink("app.js", filepath.Join(root, "repo", "an-alias.js")))
+	FollowSymlinks = true
+	defer func() { FollowSymlinks = false }()
+
+	result := walkRelative(t, root, filepath.Join(root, "repo"))
+
+	// Assert
+	assert.Equal(t, []string{"repo/an-alias.js", "repo/shared/util.js"}, result)
+}
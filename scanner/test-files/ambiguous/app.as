@@ -0,0 +1,2 @@
+import mx.controls.Button;
+var b:Button = new Button();
@@ -0,0 +1,4 @@
+% computes the area of a circle
+function a = area(r)
+    a = pi * r ^ 2;
+end
@@ -0,0 +1,6 @@
+#ifndef PLAIN_H
+#define PLAIN_H
+
+int add(int a, int b);
+
+#endif
@@ -0,0 +1,2 @@
+import flash.display.Sprite;
+var s:Sprite = new Sprite();
@@ -0,0 +1,5 @@
+#import <Foundation/Foundation.h>
+
+@interface View : NSObject
+@property int width;
+@end
@@ -0,0 +1,5 @@
+#import "view.h"
+
+@implementation View
+// draws the view
+@end
@@ -0,0 +1,9 @@
+#pragma once
+#include <string>
+
+namespace ui {
+class Widget {
+public:
+    std::string name;
+};
+}
@@ -0,0 +1,9 @@
+#include <stdio.h>
+
+int x = 1; /* start of a long
+   comment that continues
+   over several lines */
+int y = 2; /* one line */ int z = 3; /* and another
+   that keeps going */ int w = 4;
+static const char kFilter[] = "*Test/*"; // opener inside a string
+int main(void) { return x + y + z + w; }
@@ -0,0 +1,2 @@
+﻿// a comment on the first line
+const greeting = "hello";
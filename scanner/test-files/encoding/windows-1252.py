@@ -0,0 +1,5 @@
+# Smart quotes from a Windows editor  10 
+name = "caf"
+
+
+print(name)
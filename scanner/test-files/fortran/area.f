@@ -0,0 +1,14 @@
+C     COMPUTES THE AREA OF A CIRCLE
+c     LOWER CASE COMMENT
+*     STAR COMMENT
+!     BANG COMMENT
+      PROGRAM AREA                                                      AREA0001
+      REAL R, A                                                         AREA0002
+
+      PRINT *, 'RADIUS? ! NOT A COMMENT'
+      READ *, R
+      A = 3.14159 * R                                                   AREA0005
+     1    * R
+      PRINT *, A  ! TRAILING COMMENT
+   10 CONTINUE
+      END
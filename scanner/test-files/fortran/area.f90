@@ -0,0 +1,9 @@
+! computes the area of a circle
+program area
+  implicit none
+  real :: r, a
+
+  read *, r
+  a = 3.14159 * r * r ! pi r squared
+  print *, 'Circle area: ', a
+end program area
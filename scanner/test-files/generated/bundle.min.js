@@ -0,0 +1,2 @@
+/*! bundle v1.0.0 | MIT License */
+function f0(a,b){return a.map(function(c){return c*b+0}).filter(Boolean)}function f1(a,b){return a.map(function(c){return c*b+1}).filter(Boolean)}function f2(a,b){return a.map(function(c){return c*b+2}).filter(Boolean)}function f3(a,b){return a.map(function(c){return c*b+3}).filter(Boolean)}function f4(a,b){return a.map(function(c){return c*b+4}).filter(Boolean)}function f5(a,b){return a.map(function(c){return c*b+5}).filter(Boolean)}function f6(a,b){return a.map(function(c){return c*b+6}).filter(Boolean)}function f7(a,b){return a.map(function(c){return c*b+7}).filter(Boolean)}function f8(a,b){return a.map(function(c){return c*b+8}).filter(Boolean)}function f9(a,b){return a.map(function(c){return c*b+9}).filter(Boolean)}function f10(a,b){return a.map(function(c){return c*b+10}).filter(Boolean)}function f11(a,b){return a.map(function(c){return c*b+11}).filter(Boolean)}function f12(a,b){return a.map(function(c){return c*b+12}).filter(Boolean)}function f13(a,b){return a.map(function(c){return c*b+13}).filter(Boolean)}function f14(a,b){return a.map(function(c){return c*b+14}).filter(Boolean)}function f15(a,b){return a.map(function(c){return c*b+15}).filter(Boolean)}function f16(a,b){return a.map(function(c){return c*b+16}).filter(Boolean)}function f17(a,b){return a.map(function(c){return c*b+17}).filter(Boolean)}function f18(a,b){return a.map(function(c){return c*b+18}).filter(Boolean)}export{f0,f1};
@@ -0,0 +1,12 @@
+/**
+ * This file was produced from schema.graphql.
+ *
+ * @generated
+ */
+
+export type User = {
+  id: string;
+  name: string;
+};
+
+export const USER_FIELDS = ["id", "name"];
@@ -0,0 +1,11 @@
+{- outer comment
+   {- inner comment -}
+   main = hidden
+-}
+module Main where
+
+-- line comment
+main :: IO ()
+main = do
+  {- a {- b -} c -} putStrLn "{- not a comment"
+  putStrLn "done"
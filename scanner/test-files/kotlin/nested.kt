@@ -0,0 +1,13 @@
+/*
+ * /* nested block */
+ * fun hidden() = 1
+ */
+package example
+
+fun main() {
+    /* start /* nested */ still a comment */
+    val s = "*/"
+
+    // done
+    println(s)
+}
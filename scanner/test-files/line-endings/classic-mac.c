@@ -0,0 +1 @@
+// adds two numbersint add(int a, int b){    return a + b; /* sum */}
\ No newline at end of file
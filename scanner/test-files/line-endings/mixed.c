@@ -0,0 +1,6 @@
+// adds two numbers
+int add(int a, int b)
+{
+
+    return a + b; /* sum */
+}
@@ -0,0 +1,8 @@
+// adds two numbers
+int add(int a, int b)
+{
+
+    return a + b; /* sum */
+}
+
+
@@ -0,0 +1 @@
+Jtek%V,iq'hVjή.-px\GzjLnbLVhyk,z~E#6(1S6gǣXN44"Bȁ3oѠyxT^9YL!O;rn0\H.^;ܥyscݪNr?:~D9}+>Lptؕu|7,T64o4"X$FB(ueY4]Jh9Ɗ-)oZz³Z0IWv͟4ݤA=_5LNї}݄L{Kː/+&1TzӖDW#+B"Cd5Ì8qδFC؄A#@ufyLT؍1q5vm&5!KҾ%ϷNK]ǳ@BT#qkJE|d+2ܚQq_À[uA?tQxɋomA͢"_ęyF\g+Fa
\ No newline at end of file
@@ -0,0 +1,8 @@
+(* outer comment
+   (* inner comment *)
+   let hidden = 1
+*)
+let () =
+  (* a (* b *) c *) print_endline "(* not a comment";
+
+  print_endline "done"
@@ -0,0 +1,33 @@
+"""Module docstring.
+
+Spans several lines.
+"""
+import re
+
+
+class Greeter:
+    '''Class docstring using single quotes.'''
+
+    def greet(self, name):
+        r"""Raw docstring with a \d regex
+        and a # that is not a comment.
+        """
+        template = """Hello, {name}!
+        # not a comment, this is part of a string
+        """
+        return template.format(name=name)
+
+    def shout(self, name):
+        f'''Prefixed docstrings count as documentation too.'''
+        return self.greet(name).upper()
+
+
+PATTERN = re.compile(
+    """
+    ^[a-z]+$
+    """
+)
+HELP = """usage""".strip()
+VALUE = 1 + \
+    """continued"""
+# trailing comment
@@ -0,0 +1,11 @@
+/* outer
+   /* inner */
+   fn hidden() {}
+*/
+fn main() {
+    /* /* */ */ let x = 1;
+
+    // comment
+    let s = "/* not a comment";
+    println!("{} {}", x, s);
+}
@@ -0,0 +1,16 @@
+/*
+ * Outer
+ * /* inner */
+ * still a comment
+ */
+object Main {
+
+  /* /* */ */ val x = 1
+
+  def main(args: Array[String]): Unit = {
+    /* commented out
+    /* println("nested") */
+    println("still commented") */
+    println("/* not a comment */")
+  }
+}
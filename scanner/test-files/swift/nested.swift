@@ -0,0 +1,15 @@
+/* Outer comment
+   /* nested comment */
+   still inside the outer comment
+*/
+import Foundation
+
+/* one /* two /* three */ two */ one */ let a = 1
+
+func greet(name: String) -> String {
+    // line comment
+    /*
+     * let b = 2 /* nested */
+     */
+    return "Hello, /* \(name)"
+}
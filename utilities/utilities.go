@@ -6,6 +6,7 @@ import (
 	"go-cloc/scanner"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -20,11 +21,19 @@ const (
 
 type CLIArgs struct {
 	LogLevel                        string
-	LocalScanFilePath               string
-	IgnorePatterns                  []string
+	LocalScanFilePaths              []string
+	IgnoreFiles                     []scanner.IgnoreFile
 	CsvFilePath                     string
 	HtmlReportsDirectoryPath        string
 	OverrideLanguagesConfigFilePath string
+	MixedLinePolicy                 scanner.MixedLinePolicy
+	ExcludeGenerated                bool
+	Encoding                        scanner.Encoding
+	Workers                         int
+	MaxLineLength                   int
+	UseVCSIgnoreFiles               bool
+	Filter                          scanner.FileFilter
+	FollowSymlinks                  bool
 }
 
 func CleanLocalFilePath(targetPath string) string {
@@ -36,9 +45,21 @@ func CleanLocalFilePath(targetPath string) string {
 	return targetPath
 }
 
+// splits a comma separated CLI argument, ex: "Golang, Java" into ["Golang", "Java"]
+func splitList(value string) []string {
+	values := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
+
 func ParseArgsFromCLI() CLIArgs {
 	// print out arguments
-	printLanguagesArg := flag.Bool("print-languages", false, "Prints out the supported languages, file suffixes, and comment configurations. Does not run the tool.")
+	printLanguagesArg := flag.Bool("print-languages", false, "Prints out the supported languages, file suffixes, interpreters, and comment configurations. Does not run the tool.")
 
 	// optional arguments
 	logLevelArg := flag.String("log-level", "INFO", "Log level - DEBUG, INFO, WARN, ERROR")
@@ -46,6 +67,17 @@ func ParseArgsFromCLI() CLIArgs {
 	csvFilePathArg := flag.String("csv", "", "Path to dump results to a csv file, otherwise results are printed to standard out")
 	htmlReportsDirectoryPathArg := flag.String("html", "", "Path to dump HTML reports into a specified directory, otherwise HTML reports are not generated. Note this directory must already exist.")
 	overrideLanguageConfigFilePathArg := flag.String("override-languages", "", "Path to languages configuration to override the default configuration.")
+	excludeGeneratedArg := flag.Bool("exclude-generated", false, "Excludes generated and minified files from the total lines of code. They are still listed in the reports.")
+	encodingArg := flag.String("encoding", "auto", "Encoding of the scanned files, detected per file by default - auto, utf-8, utf-16le, utf-16be, windows-1252, iso-8859-1")
+	workersArg := flag.Int("workers", runtime.GOMAXPROCS(0), "Number of files to scan in parallel, 0 uses the number of CPUs available, defaults to the number of CPUs available")
+	maxLineLengthArg := flag.Int("max-line-length", 0, "Lines longer than this many bytes are counted as data instead of code, ex: embedded data or minified bundles. 0 means no limit")
+	noVCSIgnoreArg := flag.Bool("no-vcs-ignore", false, "Does not apply the .gitignore, .ignore, .go-cloc-ignore and .git/info/exclude files found while scanning a directory.")
+	followSymlinksArg := flag.Bool("follow-symlinks", false, "Walks into directories behind symbolic links. Each file is counted once, even when several links lead to it.")
+	includeLanguagesArg := flag.String("include-lang", "", "Comma separated languages to scan, other languages are skipped, ex: 'Golang,Java'. See --print-languages for the language names")
+	excludeLanguagesArg := flag.String("exclude-lang", "", "Comma separated languages to skip, ex: 'YAML,XML'")
+	includeExtensionsArg := flag.String("include-ext", "", "Comma separated file extensions to scan, files with other extensions are skipped, ex: 'go,java'")
+	excludeExtensionsArg := flag.String("exclude-ext", "", "Comma separated file extensions to skip, ex: 'min.js,json'")
+	mixedLinesArg := flag.String("mixed-lines", "code", "How to count lines with both code and a comment, ex: 'x++; // increment' - code, comment, both")
 
 	// parse the CLI arguments
 	flag.Parse()
@@ -68,8 +100,13 @@ func ParseArgsFromCLI() CLIArgs {
 		os.Exit(-1)
 	}
 
-	// Parse any remaining flags after the first non-flag argument
-	flag.CommandLine.Parse(cliArgs[1:])
+	// Collect the paths to scan, flags may be given between and after them, ex: 'go-cloc svc-a --csv out.csv svc-b'
+	scanPaths := []string{}
+	for len(cliArgs) > 0 {
+		scanPaths = append(scanPaths, cliArgs[0])
+		flag.CommandLine.Parse(cliArgs[1:])
+		cliArgs = flag.CommandLine.Args()
+	}
 
 	// dereference all CLI args to make it easier to use
 	logLevel := *logLevelArg
@@ -77,6 +114,17 @@ func ParseArgsFromCLI() CLIArgs {
 	csvFilePath := *csvFilePathArg
 	htmlReportsDirectoryPath := *htmlReportsDirectoryPathArg
 	overrideLanguageConfigFilePath := *overrideLanguageConfigFilePathArg
+	mixedLines := *mixedLinesArg
+	excludeGenerated := *excludeGeneratedArg
+	encodingValue := *encodingArg
+	workers := *workersArg
+	maxLineLength := *maxLineLengthArg
+	useVCSIgnoreFiles := !*noVCSIgnoreArg
+	followSymlinks := *followSymlinksArg
+	includeLanguages := *includeLanguagesArg
+	excludeLanguages := *excludeLanguagesArg
+	includeExtensions := *includeExtensionsArg
+	excludeExtensions := *excludeExtensionsArg
 
 	// Check if the directory exists
 	if htmlReportsDirectoryPath != "" {
@@ -100,19 +148,58 @@ func ParseArgsFromCLI() CLIArgs {
 	logger.Debug("html-reports-directory-path: ", htmlReportsDirectoryPath)
 	logger.Debug("ignore-file-path: ", ignoreFilePath)
 	logger.Debug("override-language-config-file-path: ", overrideLanguageConfigFilePath)
-
-	// Set file path to scan
-	localScanFilePath := CleanLocalFilePath(cliArgs[0])
+	logger.Debug("mixed-lines: ", mixedLines)
+	logger.Debug("exclude-generated: ", excludeGenerated)
+	logger.Debug("encoding: ", encodingValue)
+	logger.Debug("workers: ", workers)
+	logger.Debug("max-line-length: ", maxLineLength)
+	logger.Debug("no-vcs-ignore: ", !useVCSIgnoreFiles)
+	logger.Debug("follow-symlinks: ", followSymlinks)
+	logger.Debug("include-lang: ", includeLanguages)
+	logger.Debug("exclude-lang: ", excludeLanguages)
+	logger.Debug("include-ext: ", includeExtensions)
+	logger.Debug("exclude-ext: ", excludeExtensions)
+
+	// Set file paths to scan
+	localScanFilePaths := []string{}
+	for _, scanPath := range scanPaths {
+		localScanFilePaths = append(localScanFilePaths, CleanLocalFilePath(scanPath))
+	}
 
 	// validate optional arguments
+	mixedLinePolicy, ok := scanner.ParseMixedLinePolicy(mixedLines)
+	if !ok {
+		logger.Error("Invalid mixed-lines value '", mixedLines, "'. Use: code, comment, both")
+		os.Exit(-1)
+	}
+	scanner.CountMixedLinesAs = mixedLinePolicy
+	encoding, ok := scanner.ParseEncoding(encodingValue)
+	if !ok {
+		logger.Error("Invalid encoding value '", encodingValue, "'. Use: auto, utf-8, utf-16le, utf-16be, windows-1252, iso-8859-1")
+		os.Exit(-1)
+	}
+	scanner.FileEncoding = encoding
+	// 0 is left to the pool, which uses one worker per CPU available
+	if workers < 0 {
+		logger.Error("Invalid workers value '", workers, "'. Use 0 for the number of CPUs available or a number of 1 or more")
+		os.Exit(-1)
+	}
+	if maxLineLength < 0 {
+		logger.Error("Invalid max-line-length value '", maxLineLength, "'. Use 0 for no limit or a number of bytes")
+		os.Exit(-1)
+	}
+	scanner.MaxLineLength = maxLineLength
+	scanner.UseVCSIgnoreFiles = useVCSIgnoreFiles
+	scanner.FollowSymlinks = followSymlinks
 
-	// parse ignore patterns
-	ignorePatterns := []string{}
+	// parse ignore patterns, they are relative to the directory of the ignore file
+	ignoreFiles := []scanner.IgnoreFile{}
 	if ignoreFilePath != "" {
 		logger.Debug("Parsing ignore-file ", ignoreFilePath)
-		ignorePatterns = scanner.ReadIgnoreFile(ignoreFilePath)
+		ignoreFile := scanner.LoadIgnoreFile(ignoreFilePath)
+		ignoreFiles = append(ignoreFiles, ignoreFile)
 		logger.Debug("Successfully read in the ignore-file ", ignoreFilePath)
-		logger.Debug("Ignore Patterns: ", ignorePatterns)
+		logger.Debug("Ignore Patterns: ", len(ignoreFile.Patterns), " relative to ", ignoreFile.BaseDir)
 	}
 
 	// override languages config
@@ -121,13 +208,29 @@ func ParseArgsFromCLI() CLIArgs {
 		scanner.LoadLanguages(overrideLanguageConfigFilePath)
 	}
 
+	// language names are validated against the languages config, so this happens after overriding it
+	filter, err := scanner.NewFileFilter(splitList(includeLanguages), splitList(excludeLanguages), splitList(includeExtensions), splitList(excludeExtensions))
+	if err != nil {
+		logger.Error("Invalid include-lang or exclude-lang value: ", err)
+		os.Exit(-1)
+	}
+	scanner.Filter = filter
+
 	args := CLIArgs{
 		LogLevel:                        logLevel,
-		LocalScanFilePath:               localScanFilePath,
-		IgnorePatterns:                  ignorePatterns,
+		LocalScanFilePaths:              localScanFilePaths,
+		IgnoreFiles:                     ignoreFiles,
 		CsvFilePath:                     csvFilePath,
 		HtmlReportsDirectoryPath:        htmlReportsDirectoryPath,
 		OverrideLanguagesConfigFilePath: overrideLanguageConfigFilePath,
+		MixedLinePolicy:                 mixedLinePolicy,
+		ExcludeGenerated:                excludeGenerated,
+		Encoding:                        encoding,
+		Workers:                         workers,
+		MaxLineLength:                   maxLineLength,
+		UseVCSIgnoreFiles:               useVCSIgnoreFiles,
+		Filter:                          filter,
+		FollowSymlinks:                  followSymlinks,
 	}
 
 	return args